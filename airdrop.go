@@ -0,0 +1,328 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/MixinNetwork/nfo/nft"
+	"github.com/fox-one/mixin-sdk-go"
+	"github.com/gofrs/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	AirdropStatePending = ""
+	AirdropStatePaid    = "paid"
+	AirdropStateMinted  = "minted"
+	AirdropStateFailed  = "failed"
+)
+
+// AirdropEntry is a line of the airdrop CSV, and its progress is kept in
+// the state file so that the command can be resumed
+type AirdropEntry struct {
+	Recipient string `json:"recipient"`
+	Token     string `json:"token"`
+	Hash      string `json:"hash"`
+	TraceId   string `json:"trace_id"`
+	Payment   string `json:"payment,omitempty"`
+	State     string `json:"state"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// AirdropState is the progress of the entries by token, and the sequence of
+// the last node event checked for the rejects
+type AirdropState struct {
+	Sequence uint64                   `json:"sequence"`
+	Entries  map[string]*AirdropEntry `json:"entries"`
+}
+
+// runAirdrop pays the mint of each line of the CSV (recipient, token id,
+// hash) to the MTG with the app keystore, and checks the mints and rejects
+// by querying the node HTTP API
+func runAirdrop(ctx context.Context, conf *Configuration, args []string) error {
+	fs := flag.NewFlagSet("airdrop", flag.ExitOnError)
+	collection := fs.String("collection", "", "collection uuid")
+	file := fs.String("f", "", "CSV file of recipient, token id and hash")
+	state := fs.String("state", "", "state file path, defaults to the CSV path with .state.json")
+	node := fs.String("node", "", "node HTTP API, defaults to the [http] listen")
+	fs.Parse(args)
+
+	cid, err := uuid.FromString(*collection)
+	if err != nil {
+		return fmt.Errorf("invalid collection %s", *collection)
+	}
+	if *state == "" {
+		*state = *file + ".state.json"
+	}
+	if *node == "" {
+		*node = "http://" + conf.HTTP.Listen
+	}
+
+	entries, err := readAirdropCSV(*file)
+	if err != nil {
+		return err
+	}
+	progress, err := readAirdropState(*state)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if p := progress.Entries[e.Token]; p != nil {
+			e.State, e.Payment, e.Reason = p.State, p.Payment, p.Reason
+		}
+		e.TraceId = mixin.UniqueConversationID(cid.String()+":"+e.Token, "airdrop")
+		progress.Entries[e.Token] = e
+	}
+
+	client, err := mixin.NewFromKeystore(&mixin.Keystore{
+		ClientID:   conf.MTG.App.ClientId,
+		SessionID:  conf.MTG.App.SessionId,
+		PrivateKey: conf.MTG.App.PrivateKey,
+		PinToken:   conf.MTG.App.PinToken,
+	})
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.State != AirdropStatePending {
+			continue
+		}
+		e.Payment, err = payAirdrop(ctx, client, conf, cid, e)
+		if err != nil {
+			return errors.Join(err, writeAirdropState(*state, progress))
+		}
+		e.State = AirdropStatePaid
+		err = writeAirdropState(*state, progress)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = checkAirdropRejects(ctx, *node, cid, conf.MTG.App.ClientId, progress)
+	if err != nil {
+		return errors.Join(err, writeAirdropState(*state, progress))
+	}
+	var minted int
+	for _, e := range entries {
+		if e.State == AirdropStatePaid {
+			ok, err := readAirdropMint(ctx, *node, cid, e.Token)
+			if err != nil {
+				return errors.Join(err, writeAirdropState(*state, progress))
+			} else if ok {
+				e.State = AirdropStateMinted
+			}
+		}
+		if e.State == AirdropStateMinted {
+			minted += 1
+		}
+		fmt.Printf("%s %s %s %s %s\n", e.Recipient, e.Token, e.TraceId, e.State, e.Reason)
+	}
+	fmt.Printf("%d/%d minted\n", minted, len(entries))
+	return writeAirdropState(*state, progress)
+}
+
+// payAirdrop pays the mint of the entry, and returns the transaction hash of
+// the payment, which is recorded by the reject event if the mint fails
+func payAirdrop(ctx context.Context, client *mixin.Client, conf *Configuration, collection uuid.UUID, e *AirdropEntry) (string, error) {
+	token, _ := hex.DecodeString(e.Token)
+	var hash crypto.Hash
+	if e.Hash != "" {
+		b, _ := hex.DecodeString(e.Hash)
+		copy(hash[:], b)
+	}
+	nfo := nft.BuildMintNFOWithReceivers(collection.String(), token, hash, []string{e.Recipient}, 1)
+	in := &mixin.TransferInput{
+		AssetID: nft.MintAssetId,
+		Amount:  decimal.RequireFromString(nft.MintMinimumCost),
+		TraceID: e.TraceId,
+		Memo:    base64.RawURLEncoding.EncodeToString(nfo),
+	}
+	in.OpponentMultisig.Receivers = conf.MTG.Genesis.Members
+	in.OpponentMultisig.Threshold = uint8(conf.MTG.Genesis.Threshold)
+	tx, err := client.Transaction(ctx, in, conf.MTG.App.PIN)
+	if err != nil {
+		return "", err
+	}
+	return tx.TransactionHash, nil
+}
+
+// checkAirdropRejects pages through the node events after the sequence of
+// the state, and marks the entries failed by the rejects of their payments
+func checkAirdropRejects(ctx context.Context, node string, collection uuid.UUID, app string, progress *AirdropState) error {
+	payments := make(map[string]*AirdropEntry)
+	for _, e := range progress.Entries {
+		if e.State == AirdropStatePaid && e.Payment != "" {
+			payments[e.Payment] = e
+		}
+	}
+	for {
+		events, err := readAirdropEvents(ctx, node, progress.Sequence)
+		if err != nil || len(events) == 0 {
+			return err
+		}
+		for _, ev := range events {
+			progress.Sequence = ev.Sequence
+			if ev.Kind != nft.EventReject || ev.User != app || ev.Collection != collection.String() {
+				continue
+			}
+			e := payments[ev.Hash]
+			if e == nil || e.Token != ev.Token {
+				continue
+			}
+			e.State, e.Reason = AirdropStateFailed, ev.Reason
+		}
+	}
+}
+
+func readAirdropEvents(ctx context.Context, node string, offset uint64) ([]*nft.Event, error) {
+	query := url.Values{}
+	query.Set("offset", fmt.Sprint(offset))
+	query.Set("limit", fmt.Sprint(eventsLimitMax))
+	query.Set("timeout", "0")
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, node+"/events?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("node %s status %d", node, resp.StatusCode)
+	}
+
+	var body struct {
+		Events []*nft.Event `json:"events"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	return body.Events, err
+}
+
+func readAirdropMint(ctx context.Context, node string, collection uuid.UUID, token string) (bool, error) {
+	query := url.Values{}
+	query.Set("collection", collection.String())
+	query.Set("token", token)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, node+"/tokens?"+query.Encode(), nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("node %s status %d", node, resp.StatusCode)
+	}
+}
+
+// readAirdropCSV reads the lines of recipient, token id and hash, the token
+// id is a decimal integer and the hash is optional hex
+func readAirdropCSV(path string) ([]*AirdropEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	var entries []*AirdropEntry
+	filter := make(map[string]bool)
+	for line := 1; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			return entries, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "recipient") {
+			continue
+		}
+		if len(record) < 2 || len(record) > 3 {
+			return nil, fmt.Errorf("invalid airdrop line %d", line)
+		}
+		recipient, err := uuid.FromString(strings.TrimSpace(record[0]))
+		if err != nil || recipient == uuid.Nil {
+			return nil, fmt.Errorf("invalid airdrop recipient at line %d", line)
+		}
+		id, valid := new(big.Int).SetString(strings.TrimSpace(record[1]), 10)
+		if !valid || id.Sign() <= 0 {
+			return nil, fmt.Errorf("invalid airdrop token id at line %d", line)
+		}
+		e := &AirdropEntry{
+			Recipient: recipient.String(),
+			Token:     hex.EncodeToString(id.Bytes()),
+		}
+		if len(record) == 3 {
+			e.Hash = strings.TrimSpace(record[2])
+		}
+		if b, err := hex.DecodeString(e.Hash); err != nil || (len(b) != 0 && len(b) != len(crypto.Hash{})) {
+			return nil, fmt.Errorf("invalid airdrop hash at line %d", line)
+		}
+		if filter[e.Token] {
+			return nil, fmt.Errorf("duplicated airdrop token id at line %d", line)
+		}
+		filter[e.Token] = true
+		entries = append(entries, e)
+	}
+}
+
+func readAirdropState(path string) (*AirdropState, error) {
+	progress := &AirdropState{Entries: make(map[string]*AirdropEntry)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return progress, nil
+	} else if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, progress)
+	if err != nil {
+		return nil, err
+	}
+	if progress.Entries != nil {
+		return progress, nil
+	}
+	err = json.Unmarshal(data, &progress.Entries)
+	if err != nil || progress.Entries == nil {
+		progress.Entries = make(map[string]*AirdropEntry)
+	}
+	return progress, nil
+}
+
+func writeAirdropState(path string, progress *AirdropState) error {
+	data, err := json.MarshalIndent(progress, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(path+".tmp", data, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
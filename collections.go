@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MixinNetwork/nfo/nft"
+	"github.com/gofrs/uuid"
+)
+
+const (
+	collectionsSearchLimitDefault = 20
+	collectionsSearchLimitMax     = 100
+)
+
+type CollectionView struct {
+	Collection  string   `json:"collection"`
+	Creator     string   `json:"creator"`
+	Circulation int      `json:"circulation"`
+	Name        string   `json:"name,omitempty"`
+	Symbol      string   `json:"symbol,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Icon        string   `json:"icon,omitempty"`
+	URL         string   `json:"url,omitempty"`
+	Minters     []string `json:"minters,omitempty"`
+}
+
+type CollectionStatsView struct {
+	Collection  string            `json:"collection"`
+	Circulation int               `json:"circulation"`
+	Minted      int               `json:"minted"`
+	Burned      int               `json:"burned"`
+	Minters     int               `json:"minters"`
+	Sales       int               `json:"sales"`
+	Purchases   int               `json:"purchases"`
+	FirstMintAt time.Time         `json:"first_mint_at"`
+	LastMintAt  time.Time         `json:"last_mint_at"`
+	Fees        map[string]string `json:"fees"`
+	Volume      map[string]string `json:"volume"`
+	Proceeds    map[string]string `json:"proceeds"`
+}
+
+type MinterChangeView struct {
+	User      string    `json:"user"`
+	Granted   bool      `json:"granted"`
+	Sender    string    `json:"sender"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// handleCollection reads the collection by the uuid or the registered symbol
+func (s *Server) handleCollection(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var og *nft.Collection
+	var err error
+	if symbol := query.Get("symbol"); symbol != "" {
+		og, err = s.store.ReadCollectionBySymbol(strings.ToUpper(symbol))
+	} else if collection, e := uuid.FromString(query.Get("collection")); e == nil {
+		og, err = s.store.ReadMintCollection(collection.Bytes())
+	} else {
+		renderError(w, http.StatusBadRequest, fmt.Errorf("invalid collection %s", query.Get("collection")))
+		return
+	}
+	if err != nil {
+		renderError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if og == nil {
+		renderError(w, http.StatusNotFound, fmt.Errorf("collection not found"))
+		return
+	}
+	renderJSON(w, http.StatusOK, buildCollectionView(og))
+}
+
+func buildCollectionView(og *nft.Collection) *CollectionView {
+	return &CollectionView{
+		Collection:  uuid.FromBytesOrNil(og.Key).String(),
+		Creator:     og.Creator,
+		Circulation: og.Circulation,
+		Name:        og.Name,
+		Symbol:      og.Symbol,
+		Description: og.Description,
+		Icon:        hex.EncodeToString(og.Icon),
+		URL:         og.URL,
+		Minters:     og.Minters,
+	}
+}
+
+// handleCollectionSearch searches the collections by the prefixes of the
+// words in their names and symbols, ranked by the circulation
+func (s *Server) handleCollectionSearch(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q := strings.TrimSpace(query.Get("q"))
+	if q == "" {
+		renderError(w, http.StatusBadRequest, fmt.Errorf("invalid query %s", q))
+		return
+	}
+	limit, err := parseLimit(query.Get("limit"), collectionsSearchLimitDefault, collectionsSearchLimitMax)
+	if err != nil {
+		renderError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	cs, err := s.store.SearchCollections(q, limit)
+	if err != nil {
+		renderError(w, http.StatusInternalServerError, err)
+		return
+	}
+	views := make([]*CollectionView, len(cs))
+	for i, og := range cs {
+		views[i] = buildCollectionView(og)
+	}
+	renderJSON(w, http.StatusOK, map[string]any{"collections": views})
+}
+
+// handleMinters lists the minters log of the collection
+func (s *Server) handleMinters(w http.ResponseWriter, r *http.Request) {
+	collection, err := uuid.FromString(r.URL.Query().Get("collection"))
+	if err != nil {
+		renderError(w, http.StatusBadRequest, fmt.Errorf("invalid collection %s", r.URL.Query().Get("collection")))
+		return
+	}
+	changes, err := s.store.ListMinterChanges(collection.Bytes())
+	if err != nil {
+		renderError(w, http.StatusInternalServerError, err)
+		return
+	}
+	views := make([]*MinterChangeView, len(changes))
+	for i, c := range changes {
+		views[i] = &MinterChangeView{
+			User:      c.User,
+			Granted:   c.Granted,
+			Sender:    c.Sender,
+			CreatedAt: c.CreatedAt,
+		}
+	}
+	renderJSON(w, http.StatusOK, map[string]any{"changes": views})
+}
+
+// handleCollectionStats reads the aggregated stats of the collection
+func (s *Server) handleCollectionStats(w http.ResponseWriter, r *http.Request) {
+	collection, err := uuid.FromString(r.URL.Query().Get("collection"))
+	if err != nil {
+		renderError(w, http.StatusBadRequest, fmt.Errorf("invalid collection %s", r.URL.Query().Get("collection")))
+		return
+	}
+	og, err := s.store.ReadMintCollection(collection.Bytes())
+	if err != nil {
+		renderError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if og == nil {
+		renderError(w, http.StatusNotFound, fmt.Errorf("collection not found"))
+		return
+	}
+	stats, err := s.store.ReadCollectionStats(og.Key)
+	if err != nil {
+		renderError(w, http.StatusInternalServerError, err)
+		return
+	}
+	renderJSON(w, http.StatusOK, &CollectionStatsView{
+		Collection:  collection.String(),
+		Circulation: og.Circulation,
+		Minted:      stats.Minted,
+		Burned:      stats.Burned,
+		Minters:     stats.Minters,
+		Sales:       stats.Sales,
+		Purchases:   stats.Purchases,
+		FirstMintAt: stats.FirstMintAt,
+		LastMintAt:  stats.LastMintAt,
+		Fees:        stats.Fees,
+		Volume:      stats.Volume,
+		Proceeds:    stats.Proceeds,
+	})
+}
+
+// runCollection prints the stats of the collection with
+// `collection stats <uuid>` by querying the node HTTP API
+func runCollection(ctx context.Context, conf *Configuration, args []string) error {
+	if len(args) == 0 || args[0] != "stats" {
+		return fmt.Errorf("usage: collection stats [-node url] <uuid>")
+	}
+	fs := flag.NewFlagSet("collection stats", flag.ExitOnError)
+	node := fs.String("node", "", "node HTTP API, defaults to the [http] listen")
+	fs.Parse(args[1:])
+
+	collection, err := uuid.FromString(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("invalid collection %s", fs.Arg(0))
+	}
+	if *node == "" {
+		*node = "http://" + conf.HTTP.Listen
+	}
+
+	query := url.Values{}
+	query.Set("collection", collection.String())
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, *node+"/collections/stats?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("node %s status %d", *node, resp.StatusCode)
+	}
+
+	var stats CollectionStatsView
+	err = json.NewDecoder(resp.Body).Decode(&stats)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("collection\t%s\n", stats.Collection)
+	fmt.Printf("circulation\t%d\n", stats.Circulation)
+	fmt.Printf("minted\t%d\n", stats.Minted)
+	fmt.Printf("burned\t%d\n", stats.Burned)
+	fmt.Printf("minters\t%d\n", stats.Minters)
+	fmt.Printf("sales\t%d\n", stats.Sales)
+	fmt.Printf("purchases\t%d\n", stats.Purchases)
+	fmt.Printf("first mint\t%s\n", stats.FirstMintAt.Format(time.RFC3339))
+	fmt.Printf("last mint\t%s\n", stats.LastMintAt.Format(time.RFC3339))
+	printStatsAmounts("fees", stats.Fees)
+	printStatsAmounts("volume", stats.Volume)
+	printStatsAmounts("proceeds", stats.Proceeds)
+	return nil
+}
+
+func printStatsAmounts(name string, totals map[string]string) {
+	assets := make([]string, 0, len(totals))
+	for asset := range totals {
+		assets = append(assets, asset)
+	}
+	sort.Strings(assets)
+	for _, asset := range assets {
+		fmt.Printf("%s\t%s %s\n", name, totals[asset], asset)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"os"
+
+	"github.com/MixinNetwork/trusted-group/mtg"
+	"github.com/pelletier/go-toml"
+)
+
+type Configuration struct {
+	MTG  *mtg.Configuration `toml:"-"`
+	HTTP struct {
+		Listen string `toml:"listen"`
+	} `toml:"http"`
+	Webhook struct {
+		URLs        []string `toml:"urls"`
+		Secret      string   `toml:"secret"`
+		MaxAttempts int      `toml:"max-attempts"`
+	} `toml:"webhook"`
+	Log struct {
+		Level  string `toml:"level"`
+		Format string `toml:"format"`
+	} `toml:"log"`
+}
+
+func Setup(path string) (*Configuration, error) {
+	mc, err := mtg.Setup(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var conf Configuration
+	err = toml.Unmarshal(f, &conf)
+	conf.MTG = mc
+	return &conf, err
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/MixinNetwork/nfo/nft"
+)
+
+const (
+	eventsLimitDefault = 100
+	eventsLimitMax     = 500
+	eventsPollInterval = time.Second
+)
+
+// handleEvents long polls the events after the offset sequence, it
+// responds as soon as any event is available or the timeout expires
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	offset, limit, err := parseEventsQuery(r)
+	if err != nil {
+		renderError(w, http.StatusBadRequest, err)
+		return
+	}
+	timeout := 30 * time.Second
+	if t := r.URL.Query().Get("timeout"); t != "" {
+		sec, err := strconv.Atoi(t)
+		if err != nil || sec < 0 || sec > 60 {
+			renderError(w, http.StatusBadRequest, fmt.Errorf("invalid timeout %s", t))
+			return
+		}
+		timeout = time.Duration(sec) * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	events, err := s.waitEvents(ctx, offset, limit)
+	if err != nil {
+		renderError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if events == nil {
+		events = []*nft.Event{}
+	}
+	renderJSON(w, http.StatusOK, map[string]any{"events": events})
+}
+
+// handleEventsStream serves the events as server-sent events, a client
+// resumes with the Last-Event-ID header or the offset query
+func (s *Server) handleEventsStream(w http.ResponseWriter, r *http.Request) {
+	offset, limit, err := parseEventsQuery(r)
+	if err != nil {
+		renderError(w, http.StatusBadRequest, err)
+		return
+	}
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		offset, err = strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			renderError(w, http.StatusBadRequest, fmt.Errorf("invalid Last-Event-ID %s", id))
+			return
+		}
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		renderError(w, http.StatusInternalServerError, fmt.Errorf("streaming unsupported"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		events, err := s.waitEvents(r.Context(), offset, limit)
+		if err != nil || r.Context().Err() != nil {
+			return
+		}
+		for _, ev := range events {
+			data, err := json.Marshal(ev)
+			if err != nil {
+				panic(err)
+			}
+			_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.Sequence, ev.Kind, data)
+			if err != nil {
+				return
+			}
+			offset = ev.Sequence
+		}
+		flusher.Flush()
+	}
+}
+
+func (s *Server) waitEvents(ctx context.Context, offset uint64, limit int) ([]*nft.Event, error) {
+	for {
+		events, err := s.store.ListEvents(offset, limit)
+		if err != nil || len(events) > 0 {
+			return events, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case <-time.After(eventsPollInterval):
+		}
+	}
+}
+
+func parseEventsQuery(r *http.Request) (uint64, int, error) {
+	var offset uint64
+	limit := eventsLimitDefault
+	query := r.URL.Query()
+	if o := query.Get("offset"); o != "" {
+		n, err := strconv.ParseUint(o, 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid offset %s", o)
+		}
+		offset = n
+	}
+	if l := query.Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n <= 0 || n > eventsLimitMax {
+			return 0, 0, fmt.Errorf("invalid limit %s", l)
+		}
+		limit = n
+	}
+	return offset, limit, nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/MixinNetwork/trusted-group/mtg"
+)
+
+const (
+	watchdogReadyInterval = 5 * time.Second
+	watchdogStaleDuration = 10 * time.Minute
+)
+
+type Health struct {
+	Badger       bool      `json:"badger"`
+	Synced       bool      `json:"synced"`
+	SyncedAt     time.Time `json:"synced_at"`
+	Blaze        bool      `json:"blaze"`
+	ProcessedAt  time.Time `json:"processed_at"`
+	OutputAt     time.Time `json:"output_at"`
+	Transactions struct {
+		Initial int `json:"initial"`
+		Signing int `json:"signing"`
+	} `json:"transactions"`
+	CollectibleTransactions struct {
+		Initial int `json:"initial"`
+		Signing int `json:"signing"`
+	} `json:"collectible_transactions"`
+}
+
+func (s *Server) readHealth() (*Health, error) {
+	h := &Health{Badger: !s.store.IsClosed()}
+	if !h.Badger {
+		return h, nil
+	}
+	h.SyncedAt = s.mon.LastSynced()
+	h.Synced = !h.SyncedAt.IsZero()
+	h.Blaze = s.rw.Connected()
+	h.ProcessedAt, h.OutputAt = s.mon.LastProcessed()
+
+	var err error
+	h.Transactions.Initial, err = s.store.CountTransactions(mtg.TransactionStateInitial)
+	if err != nil {
+		return h, err
+	}
+	h.Transactions.Signing, err = s.store.CountTransactions(mtg.TransactionStateSigning)
+	if err != nil {
+		return h, err
+	}
+	h.CollectibleTransactions.Initial, err = s.store.CountCollectibleTransactions(mtg.TransactionStateInitial)
+	if err != nil {
+		return h, err
+	}
+	h.CollectibleTransactions.Signing, err = s.store.CountCollectibleTransactions(mtg.TransactionStateSigning)
+	return h, err
+}
+
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	h, err := s.readHealth()
+	if err != nil || !h.Badger {
+		renderJSON(w, http.StatusServiceUnavailable, h)
+		return
+	}
+	renderJSON(w, http.StatusOK, h)
+}
+
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	h, err := s.readHealth()
+	if err != nil || !h.Badger || !h.Synced {
+		renderJSON(w, http.StatusServiceUnavailable, h)
+		return
+	}
+	renderJSON(w, http.StatusOK, h)
+}
+
+// notifySystemd implements the sd_notify protocol, it does nothing
+// unless the node is started by systemd with Type=notify
+func notifySystemd(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+	addr := &net.UnixAddr{Name: socket, Net: "unixgram"}
+	conn, err := net.DialUnix(addr.Net, nil, addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(state))
+	return err
+}
+
+// loopWatchdog notifies systemd the node is ready once the group is synced,
+// then pings the watchdog at half of WatchdogSec as long as the node is
+// still healthy, so that a stuck node is restarted by systemd
+func (s *Server) loopWatchdog(ctx context.Context) {
+	interval, watchdog := watchdogReadyInterval, false
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err == nil && usec > 0 {
+		interval, watchdog = time.Duration(usec)*time.Microsecond/2, true
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var ready bool
+	for {
+		h, err := s.readHealth()
+		if err == nil && h.isAlive(time.Now()) {
+			if !ready {
+				ready = true
+				err = notifySystemd("READY=1")
+				if err != nil {
+					slog.Error("Server.notifySystemd", "state", "READY=1", "error", err)
+				}
+			}
+			if watchdog {
+				err = notifySystemd("WATCHDOG=1")
+				if err != nil {
+					slog.Error("Server.notifySystemd", "state", "WATCHDOG=1", "error", err)
+				}
+			}
+		}
+		if ready && !watchdog {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// isAlive checks whether the group has synced since the node started, and
+// is still draining or processing the outputs
+func (h *Health) isAlive(now time.Time) bool {
+	if !h.Badger || !h.Synced {
+		return false
+	}
+	last := h.SyncedAt
+	if h.ProcessedAt.After(last) {
+		last = h.ProcessedAt
+	}
+	return now.Sub(last) < watchdogStaleDuration
+}
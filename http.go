@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/MixinNetwork/nfo/store"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+type Server struct {
+	store *store.BadgerStore
+	mon   *MonitorWorker
+	rw    *MessengerWorker
+	mux   *http.ServeMux
+}
+
+func NewServer(store *store.BadgerStore, mon *MonitorWorker, rw *MessengerWorker) *Server {
+	prometheus.MustRegister(NewStoreCollector(store))
+
+	s := &Server{
+		store: store,
+		mon:   mon,
+		rw:    rw,
+		mux:   http.NewServeMux(),
+	}
+	s.mux.Handle("/metrics", promhttp.Handler())
+	s.mux.HandleFunc("/healthz", s.handleHealthz)
+	s.mux.HandleFunc("/readyz", s.handleReadyz)
+	s.mux.HandleFunc("/events", s.handleEvents)
+	s.mux.HandleFunc("/events/stream", s.handleEventsStream)
+	s.mux.HandleFunc("/tokens", s.handleToken)
+	s.mux.HandleFunc("/tokens/traits", s.handleTokensByTrait)
+	s.mux.HandleFunc("/tokens/provenance", s.handleProvenance)
+	s.mux.HandleFunc("/collections", s.handleCollection)
+	s.mux.HandleFunc("/collections/traits", s.handleTraits)
+	s.mux.HandleFunc("/collections/minters", s.handleMinters)
+	s.mux.HandleFunc("/collections/stats", s.handleCollectionStats)
+	s.mux.HandleFunc("/collections/search", s.handleCollectionSearch)
+	s.mux.HandleFunc("/users/mints", s.handleUserMints)
+	return s
+}
+
+func (s *Server) Run(ctx context.Context, listen string) {
+	srv := &http.Server{
+		Addr:              listen,
+		Handler:           s.mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	err := srv.ListenAndServe()
+	slog.Error("Server.Run", "listen", listen, "error", err)
+}
+
+func renderJSON(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(data)
+	if err != nil {
+		slog.Debug("renderJSON", "error", err)
+	}
+}
+
+func renderError(w http.ResponseWriter, status int, err error) {
+	renderJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/MixinNetwork/mixin/logger"
+)
+
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// setupLogger installs the default slog logger, the mixin logger used by
+// the mtg package is routed through it by the standard log package
+func setupLogger(level, format string) error {
+	var l slog.Level
+	switch level {
+	case "debug":
+		l = slog.LevelDebug
+		logger.SetLevel(logger.VERBOSE)
+	case "", "info":
+		l = slog.LevelInfo
+		logger.SetLevel(logger.INFO)
+	case "warn":
+		l = slog.LevelWarn
+		logger.SetLevel(logger.ERROR)
+	case "error":
+		l = slog.LevelError
+		logger.SetLevel(logger.ERROR)
+	default:
+		return fmt.Errorf("invalid log level %s", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: l}
+	switch format {
+	case "", LogFormatText:
+		slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, opts)))
+	case LogFormatJSON:
+		slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, opts)))
+	default:
+		return fmt.Errorf("invalid log format %s", format)
+	}
+	return nil
+}
@@ -7,14 +7,14 @@ import (
 	"path/filepath"
 	"strings"
 
-	"github.com/MixinNetwork/mixin/logger"
 	"github.com/MixinNetwork/trusted-group/mtg"
+	"github.com/MixinNetwork/nfo/market"
 	"github.com/MixinNetwork/nfo/nft"
 	"github.com/MixinNetwork/nfo/store"
+	"github.com/MixinNetwork/nfo/webhook"
 )
 
 func main() {
-	logger.SetLevel(logger.VERBOSE)
 	ctx := context.Background()
 
 	bp := flag.String("d", "~/.mixin/nfo/data", "database directory path")
@@ -25,10 +25,35 @@ func main() {
 		usr, _ := user.Current()
 		*cp = filepath.Join(usr.HomeDir, (*cp)[2:])
 	}
-	conf, err := mtg.Setup(*cp)
+	conf, err := Setup(*cp)
 	if err != nil {
 		panic(err)
 	}
+	err = setupLogger(conf.Log.Level, conf.Log.Format)
+	if err != nil {
+		panic(err)
+	}
+
+	switch flag.Arg(0) {
+	case "airdrop":
+		err = runAirdrop(ctx, conf, flag.Args()[1:])
+		if err != nil {
+			panic(err)
+		}
+		return
+	case "collection":
+		err = runCollection(ctx, conf, flag.Args()[1:])
+		if err != nil {
+			panic(err)
+		}
+		return
+	case "user":
+		err = runUser(ctx, conf, flag.Args()[1:])
+		if err != nil {
+			panic(err)
+		}
+		return
+	}
 
 	if strings.HasPrefix(*bp, "~/") {
 		usr, _ := user.Current()
@@ -40,13 +65,27 @@ func main() {
 	}
 	defer db.Close()
 
-	group, err := mtg.BuildGroup(ctx, db, conf)
+	mon := NewMonitorWorker()
+	group, err := mtg.BuildGroup(ctx, mon.Store(db), conf.MTG)
 	if err != nil {
 		panic(err)
 	}
 	mw := nft.NewMintWorker(group, db)
 	group.AddWorker(mw)
-	rw := NewMessengerWorker(ctx, group, conf)
+	kw := market.NewMarketWorker(group, db)
+	group.AddWorker(kw)
+	rw := NewMessengerWorker(ctx, group, conf.MTG, db)
 	group.AddWorker(rw)
+	group.AddWorker(mon)
+
+	srv := NewServer(db, mon, rw)
+	if conf.HTTP.Listen != "" {
+		go srv.Run(ctx, conf.HTTP.Listen)
+	}
+	go srv.loopWatchdog(ctx)
+	if len(conf.Webhook.URLs) > 0 {
+		wh := webhook.NewDispatcher(db, conf.Webhook.URLs, conf.Webhook.Secret, conf.Webhook.MaxAttempts)
+		go wh.Run(ctx)
+	}
 	group.Run(ctx)
 }
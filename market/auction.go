@@ -0,0 +1,132 @@
+package market
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/MixinNetwork/nfo/nft"
+	"github.com/MixinNetwork/trusted-group/mtg"
+	"github.com/fox-one/mixin-sdk-go"
+	"github.com/gofrs/uuid"
+)
+
+// AuctionOperation is sent along with the NFT to start an auction, the end
+// is in unix seconds and compared with the output timestamps
+type AuctionOperation struct {
+	Collection uuid.UUID `msgpack:"C"`
+	Token      []byte    `msgpack:"T"`
+	Asset      uuid.UUID `msgpack:"A"`
+	Reserve    string    `msgpack:"R"`
+	End        int64     `msgpack:"E"`
+}
+
+// BidOperation is sent along with the bid amount, which must be at least
+// the reserve and higher than the current highest bid
+type BidOperation struct {
+	Auction uuid.UUID `msgpack:"A"`
+}
+
+func (mw *MarketWorker) processAuction(ctx context.Context, out *mtg.CollectibleOutput, ao *AuctionOperation) {
+	token := mw.readDepositToken(out, ao.Collection, ao.Token)
+	reserve, valid := parsePrice(ao.Reserve)
+	end := time.Unix(ao.End, 0)
+	if token == nil || !valid || ao.Asset == uuid.Nil || !end.After(out.CreatedAt) {
+		mw.returnCollectible(ctx, out)
+		return
+	}
+
+	old, err := mw.store.ReadAuction(out.OutputId)
+	if err != nil {
+		panic(err)
+	} else if old != nil {
+		return
+	}
+	a := &Auction{
+		Id:         out.OutputId,
+		Collection: token.Collection,
+		Token:      token.Key,
+		TokenId:    out.TokenId,
+		Seller:     out.Senders[0],
+		AssetId:    ao.Asset.String(),
+		Reserve:    reserve.String(),
+		State:      AuctionStateActive,
+		EndedAt:    end,
+		CreatedAt:  out.CreatedAt,
+		UpdatedAt:  out.CreatedAt,
+	}
+	err = mw.store.WriteAuction(a, nil)
+	if err != nil {
+		panic(err)
+	}
+	slog.Info("MarketWorker.auction", "auction", a.Id, "token", a.TokenId, "seller", a.Seller, "asset", a.AssetId, "reserve", a.Reserve)
+}
+
+func (mw *MarketWorker) processBid(ctx context.Context, out *mtg.Output, bo *BidOperation) {
+	a, err := mw.store.ReadAuction(bo.Auction.String())
+	if err != nil {
+		panic(err)
+	}
+	if a == nil || a.State != AuctionStateActive || !out.CreatedAt.Before(a.EndedAt) {
+		mw.refund(ctx, out, "auction")
+		return
+	}
+	if a.BidId == out.UTXOID {
+		return
+	}
+	if out.AssetID != a.AssetId || out.Sender == a.Seller {
+		mw.refund(ctx, out, "bid")
+		return
+	}
+	reserve, _ := parsePrice(a.Reserve)
+	if out.Amount.Cmp(reserve) < 0 {
+		mw.refund(ctx, out, "bid")
+		return
+	}
+	if highest, valid := parsePrice(a.Bid); valid && out.Amount.Cmp(highest) <= 0 {
+		mw.refund(ctx, out, "bid")
+		return
+	}
+
+	if a.BidId != "" {
+		mw.refundPayment(ctx, a.BidId, a.Bidder, a.AssetId, a.Bid, "outbid", out.CreatedAt)
+	}
+	a.Bidder = out.Sender
+	a.Bid = out.Amount.String()
+	a.BidId = out.UTXOID
+	a.UpdatedAt = out.CreatedAt
+	err = mw.store.WriteAuction(a, nil)
+	if err != nil {
+		panic(err)
+	}
+	slog.Info("MarketWorker.bid", "auction", a.Id, "bidder", a.Bidder, "bid", a.Bid)
+}
+
+// settleAuction releases the NFT to the highest bidder and pays the seller,
+// or returns the NFT to the seller if there is no bid
+func (mw *MarketWorker) settleAuction(ctx context.Context, a *Auction, now time.Time) {
+	var events []*nft.Event
+	if a.BidId == "" {
+		traceId := mixin.UniqueConversationID(a.Id, "return")
+		mw.transferCollectible(ctx, a.Seller, a.TokenId, MemoReturn, traceId)
+		a.State = AuctionStateExpired
+	} else {
+		price, _ := parsePrice(a.Bid)
+		traceId := mixin.UniqueConversationID(a.Id, "release")
+		mw.transferCollectible(ctx, a.Bidder, a.TokenId, MemoRelease, traceId)
+		saleId := mixin.UniqueConversationID(a.Id, "sale")
+		err := SettleSale(ctx, mw.grp, mw.readCollection(a.Collection), a.AssetId, price, a.Seller, saleId)
+		if err != nil {
+			panic(err)
+		}
+		a.State = AuctionStateSold
+		events = append(events, buildSaleEvent(a.Id, a.Collection, a.Token, a.TokenId, a.Bidder, a.AssetId, price, traceId, now))
+	}
+
+	a.UpdatedAt = now
+	err := mw.store.WriteAuction(a, events)
+	if err != nil {
+		panic(err)
+	}
+	slog.Info("MarketWorker.settleAuction", "auction", a.Id, "token", a.TokenId, "bidder", a.Bidder, "bid", a.Bid)
+}
@@ -0,0 +1,122 @@
+package market
+
+import (
+	"time"
+
+	"github.com/MixinNetwork/nfo/nft"
+)
+
+const (
+	ListingStateActive    = 10
+	ListingStateSold      = 11
+	ListingStateCancelled = 12
+	ListingStateExpired   = 13
+
+	AuctionStateActive  = 10
+	AuctionStateSold    = 11
+	AuctionStateExpired = 12
+
+	OfferStateActive    = 10
+	OfferStateFilled    = 11
+	OfferStateCancelled = 12
+	OfferStateExpired   = 13
+
+	SwapStateActive  = 10
+	SwapStateSwapped = 11
+	SwapStateExpired = 12
+)
+
+type Store interface {
+	ReadMintCollection(collection []byte) (*nft.Collection, error)
+	ReadMintToken(collection, token []byte) (*nft.Token, error)
+	WriteEvent(event *nft.Event) error
+
+	WriteListing(l *Listing, events []*nft.Event) error
+	ReadListing(id string) (*Listing, error)
+	ListExpiredListings(until time.Time, limit int) ([]*Listing, error)
+
+	WriteAuction(a *Auction, events []*nft.Event) error
+	ReadAuction(id string) (*Auction, error)
+	ListEndedAuctions(until time.Time, limit int) ([]*Auction, error)
+
+	WriteOffer(o *Offer, events []*nft.Event) error
+	ReadOffer(id string) (*Offer, error)
+	ListExpiredOffers(until time.Time, limit int) ([]*Offer, error)
+
+	WriteSwaps(swaps []*Swap, events []*nft.Event) error
+	ReadSwap(id string) (*Swap, error)
+	ReadActiveSwap(owner, tokenId, counterparty, want string) (*Swap, error)
+	ListExpiredSwaps(until time.Time, limit int) ([]*Swap, error)
+}
+
+// Listing is a fixed price sale of an NFT escrowed by the MTG, the id is
+// the collectible output id of the escrowed NFT
+type Listing struct {
+	Id         string
+	Collection []byte
+	Token      []byte
+	TokenId    string
+	Seller     string
+	AssetId    string
+	Price      string
+	Buyer      string
+	State      int
+	ExpiredAt  time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// Auction is an English auction of an NFT escrowed by the MTG, the id is
+// the collectible output id of the escrowed NFT, and the bid id is the
+// output id of the highest bid
+type Auction struct {
+	Id         string
+	Collection []byte
+	Token      []byte
+	TokenId    string
+	Seller     string
+	AssetId    string
+	Reserve    string
+	Bidder     string
+	Bid        string
+	BidId      string
+	State      int
+	EndedAt    time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// Offer is an amount locked in the MTG to buy any token of the collection,
+// the id is the output id of the locked payment
+type Offer struct {
+	Id         string
+	Collection []byte
+	Buyer      string
+	AssetId    string
+	Amount     string
+	Token      []byte
+	TokenId    string
+	Seller     string
+	State      int
+	ExpiredAt  time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// Swap is an NFT escrowed by the MTG to be exchanged for the wanted token
+// of the counterparty, the id is the collectible output id of the escrowed
+// NFT, and the match is the id of the counterparty swap once swapped
+type Swap struct {
+	Id           string
+	Collection   []byte
+	Token        []byte
+	TokenId      string
+	Owner        string
+	Counterparty string
+	Want         string
+	Match        string
+	State        int
+	ExpiredAt    time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
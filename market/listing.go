@@ -0,0 +1,138 @@
+package market
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/MixinNetwork/nfo/nft"
+	"github.com/MixinNetwork/trusted-group/mtg"
+	"github.com/fox-one/mixin-sdk-go"
+	"github.com/gofrs/uuid"
+)
+
+// ListOperation is sent along with the NFT to list it for a fixed price,
+// the expiry is in unix seconds
+type ListOperation struct {
+	Collection uuid.UUID `msgpack:"C"`
+	Token      []byte    `msgpack:"T"`
+	Asset      uuid.UUID `msgpack:"A"`
+	Price      string    `msgpack:"P"`
+	Expiry     int64     `msgpack:"E"`
+}
+
+// BuyOperation is sent along with the exact price to buy the listing
+type BuyOperation struct {
+	Listing uuid.UUID `msgpack:"L"`
+}
+
+// CancelListOperation is sent by the seller with any amount of any asset,
+// which is refunded together with the NFT
+type CancelListOperation struct {
+	Listing uuid.UUID `msgpack:"L"`
+}
+
+func (mw *MarketWorker) processList(ctx context.Context, out *mtg.CollectibleOutput, lo *ListOperation) {
+	token := mw.readDepositToken(out, lo.Collection, lo.Token)
+	price, valid := parsePrice(lo.Price)
+	expiry := time.Unix(lo.Expiry, 0)
+	if token == nil || !valid || lo.Asset == uuid.Nil || !expiry.After(out.CreatedAt) {
+		mw.returnCollectible(ctx, out)
+		return
+	}
+
+	old, err := mw.store.ReadListing(out.OutputId)
+	if err != nil {
+		panic(err)
+	} else if old != nil {
+		return
+	}
+	l := &Listing{
+		Id:         out.OutputId,
+		Collection: token.Collection,
+		Token:      token.Key,
+		TokenId:    out.TokenId,
+		Seller:     out.Senders[0],
+		AssetId:    lo.Asset.String(),
+		Price:      price.String(),
+		State:      ListingStateActive,
+		ExpiredAt:  expiry,
+		CreatedAt:  out.CreatedAt,
+		UpdatedAt:  out.CreatedAt,
+	}
+	err = mw.store.WriteListing(l, nil)
+	if err != nil {
+		panic(err)
+	}
+	slog.Info("MarketWorker.list", "listing", l.Id, "token", l.TokenId, "seller", l.Seller, "asset", l.AssetId, "price", l.Price)
+}
+
+func (mw *MarketWorker) processBuy(ctx context.Context, out *mtg.Output, bo *BuyOperation) {
+	l, err := mw.store.ReadListing(bo.Listing.String())
+	if err != nil {
+		panic(err)
+	}
+	if l == nil || l.State != ListingStateActive || !out.CreatedAt.Before(l.ExpiredAt) {
+		mw.refund(ctx, out, "listing")
+		return
+	}
+	price, _ := parsePrice(l.Price)
+	if out.AssetID != l.AssetId || !out.Amount.Equal(price) {
+		mw.refund(ctx, out, "price")
+		return
+	}
+
+	traceId := mixin.UniqueConversationID(l.Id, "release")
+	mw.transferCollectible(ctx, out.Sender, l.TokenId, MemoRelease, traceId)
+	saleId := mixin.UniqueConversationID(l.Id, "sale")
+	err = SettleSale(ctx, mw.grp, mw.readCollection(l.Collection), l.AssetId, price, l.Seller, saleId)
+	if err != nil {
+		panic(err)
+	}
+
+	l.State = ListingStateSold
+	l.Buyer = out.Sender
+	l.UpdatedAt = out.CreatedAt
+	ev := buildSaleEvent(l.Id, l.Collection, l.Token, l.TokenId, l.Buyer, l.AssetId, price, traceId, out.CreatedAt)
+	err = mw.store.WriteListing(l, []*nft.Event{ev})
+	if err != nil {
+		panic(err)
+	}
+	slog.Info("MarketWorker.buy", "listing", l.Id, "token", l.TokenId, "buyer", l.Buyer, "trace", traceId)
+}
+
+func (mw *MarketWorker) processCancelList(ctx context.Context, out *mtg.Output, co *CancelListOperation) {
+	l, err := mw.store.ReadListing(co.Listing.String())
+	if err != nil {
+		panic(err)
+	}
+	if l == nil || l.State != ListingStateActive || l.Seller != out.Sender {
+		mw.refund(ctx, out, "listing")
+		return
+	}
+
+	traceId := mixin.UniqueConversationID(l.Id, "return")
+	mw.transferCollectible(ctx, l.Seller, l.TokenId, MemoReturn, traceId)
+	mw.refund(ctx, out, "cancel")
+
+	l.State = ListingStateCancelled
+	l.UpdatedAt = out.CreatedAt
+	err = mw.store.WriteListing(l, nil)
+	if err != nil {
+		panic(err)
+	}
+	slog.Info("MarketWorker.cancelList", "listing", l.Id, "token", l.TokenId, "trace", traceId)
+}
+
+func (mw *MarketWorker) expireListing(ctx context.Context, l *Listing, now time.Time) {
+	traceId := mixin.UniqueConversationID(l.Id, "return")
+	mw.transferCollectible(ctx, l.Seller, l.TokenId, MemoReturn, traceId)
+
+	l.State = ListingStateExpired
+	l.UpdatedAt = now
+	err := mw.store.WriteListing(l, nil)
+	if err != nil {
+		panic(err)
+	}
+	slog.Info("MarketWorker.expireListing", "listing", l.Id, "token", l.TokenId, "trace", traceId)
+}
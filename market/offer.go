@@ -0,0 +1,141 @@
+package market
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/MixinNetwork/nfo/nft"
+	"github.com/MixinNetwork/trusted-group/mtg"
+	"github.com/fox-one/mixin-sdk-go"
+	"github.com/gofrs/uuid"
+)
+
+// OfferOperation is sent along with the offered amount to buy any token
+// of the collection, the expiry is in unix seconds
+type OfferOperation struct {
+	Collection uuid.UUID `msgpack:"C"`
+	Expiry     int64     `msgpack:"E"`
+}
+
+// AcceptOperation is sent along with a token of the offer collection to
+// sell it for the offered amount
+type AcceptOperation struct {
+	Offer uuid.UUID `msgpack:"O"`
+	Token []byte    `msgpack:"T"`
+}
+
+// CancelOfferOperation is sent by the buyer with any amount of any asset,
+// which is refunded together with the offered amount
+type CancelOfferOperation struct {
+	Offer uuid.UUID `msgpack:"O"`
+}
+
+func (mw *MarketWorker) processOffer(ctx context.Context, out *mtg.Output, oo *OfferOperation) {
+	expiry := time.Unix(oo.Expiry, 0)
+	_, valid := parsePrice(out.Amount.String())
+	if !valid || !expiry.After(out.CreatedAt) {
+		mw.refund(ctx, out, "offer")
+		return
+	}
+	ck := oo.Collection.Bytes()
+	if bytes.Compare(ck, mtg.NMDefaultCollectionKey) == 0 || mw.readCollection(ck) == nil {
+		mw.refund(ctx, out, "collection")
+		return
+	}
+
+	old, err := mw.store.ReadOffer(out.UTXOID)
+	if err != nil {
+		panic(err)
+	} else if old != nil {
+		return
+	}
+	o := &Offer{
+		Id:         out.UTXOID,
+		Collection: ck,
+		Buyer:      out.Sender,
+		AssetId:    out.AssetID,
+		Amount:     out.Amount.String(),
+		State:      OfferStateActive,
+		ExpiredAt:  expiry,
+		CreatedAt:  out.CreatedAt,
+		UpdatedAt:  out.CreatedAt,
+	}
+	err = mw.store.WriteOffer(o, nil)
+	if err != nil {
+		panic(err)
+	}
+	slog.Info("MarketWorker.offer", "offer", o.Id, "collection", oo.Collection.String(), "buyer", o.Buyer, "asset", o.AssetId, "amount", o.Amount)
+}
+
+func (mw *MarketWorker) processAccept(ctx context.Context, out *mtg.CollectibleOutput, ao *AcceptOperation) {
+	o, err := mw.store.ReadOffer(ao.Offer.String())
+	if err != nil {
+		panic(err)
+	}
+	if o == nil || o.State != OfferStateActive || !out.CreatedAt.Before(o.ExpiredAt) {
+		mw.returnCollectible(ctx, out)
+		return
+	}
+	token := mw.readDepositToken(out, uuid.FromBytesOrNil(o.Collection), ao.Token)
+	if token == nil {
+		mw.returnCollectible(ctx, out)
+		return
+	}
+
+	price, _ := parsePrice(o.Amount)
+	traceId := mixin.UniqueConversationID(o.Id, "release")
+	mw.transferCollectible(ctx, o.Buyer, out.TokenId, MemoRelease, traceId)
+	saleId := mixin.UniqueConversationID(o.Id, "sale")
+	err = SettleSale(ctx, mw.grp, mw.readCollection(o.Collection), o.AssetId, price, out.Senders[0], saleId)
+	if err != nil {
+		panic(err)
+	}
+
+	o.State = OfferStateFilled
+	o.Token = token.Key
+	o.TokenId = out.TokenId
+	o.Seller = out.Senders[0]
+	o.UpdatedAt = out.CreatedAt
+	ev := buildSaleEvent(o.Id, o.Collection, o.Token, o.TokenId, o.Buyer, o.AssetId, price, traceId, out.CreatedAt)
+	err = mw.store.WriteOffer(o, []*nft.Event{ev})
+	if err != nil {
+		panic(err)
+	}
+	slog.Info("MarketWorker.accept", "offer", o.Id, "token", o.TokenId, "seller", o.Seller, "trace", traceId)
+}
+
+func (mw *MarketWorker) processCancelOffer(ctx context.Context, out *mtg.Output, co *CancelOfferOperation) {
+	o, err := mw.store.ReadOffer(co.Offer.String())
+	if err != nil {
+		panic(err)
+	}
+	if o == nil || o.State != OfferStateActive || o.Buyer != out.Sender {
+		mw.refund(ctx, out, "offer")
+		return
+	}
+
+	mw.refundPayment(ctx, o.Id, o.Buyer, o.AssetId, o.Amount, "cancel", out.CreatedAt)
+	mw.refund(ctx, out, "cancel")
+
+	o.State = OfferStateCancelled
+	o.UpdatedAt = out.CreatedAt
+	err = mw.store.WriteOffer(o, nil)
+	if err != nil {
+		panic(err)
+	}
+	slog.Info("MarketWorker.cancelOffer", "offer", o.Id)
+}
+
+func (mw *MarketWorker) expireOffer(ctx context.Context, o *Offer, now time.Time) {
+	mw.refundPayment(ctx, o.Id, o.Buyer, o.AssetId, o.Amount, "expired", now)
+
+	o.State = OfferStateExpired
+	o.UpdatedAt = now
+	err := mw.store.WriteOffer(o, nil)
+	if err != nil {
+		panic(err)
+	}
+	slog.Info("MarketWorker.expireOffer", "offer", o.Id)
+}
@@ -0,0 +1,59 @@
+package market
+
+import (
+	"context"
+
+	"github.com/MixinNetwork/nfo/nft"
+	"github.com/MixinNetwork/trusted-group/mtg"
+	"github.com/fox-one/mixin-sdk-go"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	MemoRoyalty = "ROYALTY"
+	MemoSale    = "SALE"
+)
+
+type Payout struct {
+	Receiver string
+	Amount   decimal.Decimal
+	Memo     string
+}
+
+// SplitSale splits the price to the royalty recipients of the collection,
+// and pays the rest to the seller
+func SplitSale(og *nft.Collection, price decimal.Decimal, seller string) []*Payout {
+	var payouts []*Payout
+	rest := price
+	if og != nil && og.Royalty != nil {
+		rate := decimal.NewFromInt(int64(og.Royalty.Rate))
+		total := decimal.NewFromInt(nft.RoyaltyShareTotal)
+		royalty := price.Mul(rate).Div(total)
+		for _, r := range og.Royalty.Recipients {
+			share := decimal.NewFromInt(int64(r.Share))
+			amount := royalty.Mul(share).Div(total).Truncate(8)
+			if !amount.IsPositive() {
+				continue
+			}
+			rest = rest.Sub(amount)
+			payouts = append(payouts, &Payout{Receiver: r.UserId, Amount: amount, Memo: MemoRoyalty})
+		}
+	}
+	if rest.IsPositive() {
+		payouts = append(payouts, &Payout{Receiver: seller, Amount: rest, Memo: MemoSale})
+	}
+	return payouts
+}
+
+// SettleSale builds the payout transactions of a sale, the trace ids are
+// derived from the sale trace id so that all members build the same ones
+func SettleSale(ctx context.Context, grp *mtg.Group, og *nft.Collection, assetId string, price decimal.Decimal, seller, traceId string) error {
+	for _, p := range SplitSale(og, price, seller) {
+		id := mixin.UniqueConversationID(traceId, p.Memo+":"+p.Receiver)
+		err := grp.BuildTransaction(ctx, assetId, []string{p.Receiver}, 1, p.Amount.String(), p.Memo, id, "")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package market
+
+import (
+	"testing"
+
+	"github.com/MixinNetwork/nfo/nft"
+	"github.com/shopspring/decimal"
+)
+
+func TestSplitSale(t *testing.T) {
+	const (
+		seller = "c6d0c728-2624-429b-8e0d-d9d19b6592fa"
+		alice  = "4b188942-9fb0-4b99-b4be-e741a06d1ebf"
+		bob    = "dd655520-c919-4349-822f-af92fabdbdf4"
+	)
+	royalty := func(rate int, recipients ...*nft.RoyaltyRecipient) *nft.Collection {
+		return &nft.Collection{Royalty: &nft.Royalty{Rate: rate, Recipients: recipients}}
+	}
+
+	tests := []struct {
+		name    string
+		og      *nft.Collection
+		price   string
+		payouts []*Payout
+	}{{
+		name:  "no collection",
+		price: "1",
+		payouts: []*Payout{
+			{Receiver: seller, Amount: decimal.RequireFromString("1"), Memo: MemoSale},
+		},
+	}, {
+		name:  "no royalty",
+		og:    &nft.Collection{},
+		price: "1",
+		payouts: []*Payout{
+			{Receiver: seller, Amount: decimal.RequireFromString("1"), Memo: MemoSale},
+		},
+	}, {
+		name:  "single recipient",
+		og:    royalty(500, &nft.RoyaltyRecipient{UserId: alice, Share: 10000}),
+		price: "1",
+		payouts: []*Payout{
+			{Receiver: alice, Amount: decimal.RequireFromString("0.05"), Memo: MemoRoyalty},
+			{Receiver: seller, Amount: decimal.RequireFromString("0.95"), Memo: MemoSale},
+		},
+	}, {
+		name: "uneven shares",
+		og: royalty(1000,
+			&nft.RoyaltyRecipient{UserId: alice, Share: 3333},
+			&nft.RoyaltyRecipient{UserId: bob, Share: 6667}),
+		price: "1",
+		payouts: []*Payout{
+			{Receiver: alice, Amount: decimal.RequireFromString("0.03333"), Memo: MemoRoyalty},
+			{Receiver: bob, Amount: decimal.RequireFromString("0.06667"), Memo: MemoRoyalty},
+			{Receiver: seller, Amount: decimal.RequireFromString("0.9"), Memo: MemoSale},
+		},
+	}, {
+		name:  "truncated to satoshi",
+		og:    royalty(250, &nft.RoyaltyRecipient{UserId: alice, Share: 10000}),
+		price: "1.23456789",
+		payouts: []*Payout{
+			{Receiver: alice, Amount: decimal.RequireFromString("0.03086419"), Memo: MemoRoyalty},
+			{Receiver: seller, Amount: decimal.RequireFromString("1.2037037"), Memo: MemoSale},
+		},
+	}, {
+		name: "rounding dust to seller",
+		og: royalty(1000,
+			&nft.RoyaltyRecipient{UserId: alice, Share: 5000},
+			&nft.RoyaltyRecipient{UserId: bob, Share: 5000}),
+		price: "0.00000003",
+		payouts: []*Payout{
+			{Receiver: seller, Amount: decimal.RequireFromString("0.00000003"), Memo: MemoSale},
+		},
+	}, {
+		name: "dust royalty skipped",
+		og: royalty(5000,
+			&nft.RoyaltyRecipient{UserId: alice, Share: 9999},
+			&nft.RoyaltyRecipient{UserId: bob, Share: 1}),
+		price: "0.0001",
+		payouts: []*Payout{
+			{Receiver: alice, Amount: decimal.RequireFromString("0.00004999"), Memo: MemoRoyalty},
+			{Receiver: seller, Amount: decimal.RequireFromString("0.00005001"), Memo: MemoSale},
+		},
+	}, {
+		name:  "full royalty",
+		og:    royalty(10000, &nft.RoyaltyRecipient{UserId: alice, Share: 10000}),
+		price: "2",
+		payouts: []*Payout{
+			{Receiver: alice, Amount: decimal.RequireFromString("2"), Memo: MemoRoyalty},
+		},
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			price := decimal.RequireFromString(tc.price)
+			payouts := SplitSale(tc.og, price, seller)
+			if len(payouts) != len(tc.payouts) {
+				t.Fatalf("payouts %d, want %d", len(payouts), len(tc.payouts))
+			}
+			sum := decimal.Zero
+			for i, p := range payouts {
+				want := tc.payouts[i]
+				if p.Receiver != want.Receiver || p.Memo != want.Memo || !p.Amount.Equal(want.Amount) {
+					t.Fatalf("payout %d %s %s %s, want %s %s %s", i,
+						p.Receiver, p.Amount, p.Memo, want.Receiver, want.Amount, want.Memo)
+				}
+				if !p.Amount.Equal(p.Amount.Truncate(8)) {
+					t.Fatalf("payout %d amount %s has dust", i, p.Amount)
+				}
+				sum = sum.Add(p.Amount)
+			}
+			if !sum.Equal(price) {
+				t.Fatalf("payouts sum %s, want %s", sum, price)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package market
+
+import (
+	"context"
+	"encoding/hex"
+	"log/slog"
+	"time"
+
+	"github.com/MixinNetwork/nfo/nft"
+	"github.com/MixinNetwork/trusted-group/mtg"
+	"github.com/fox-one/mixin-sdk-go"
+	"github.com/gofrs/uuid"
+)
+
+// SwapOperation is sent along with the NFT to exchange it for the wanted
+// kernel token id deposited by the counterparty, the expiry is in unix seconds
+type SwapOperation struct {
+	Collection   uuid.UUID `msgpack:"C"`
+	Token        []byte    `msgpack:"T"`
+	Counterparty uuid.UUID `msgpack:"U"`
+	Want         uuid.UUID `msgpack:"W"`
+	Expiry       int64     `msgpack:"E"`
+}
+
+func (mw *MarketWorker) processSwap(ctx context.Context, out *mtg.CollectibleOutput, so *SwapOperation) {
+	token := mw.readDepositToken(out, so.Collection, so.Token)
+	expiry := time.Unix(so.Expiry, 0)
+	if token == nil || !expiry.After(out.CreatedAt) {
+		mw.returnCollectible(ctx, out)
+		return
+	}
+	if so.Want == uuid.Nil || so.Want.String() == out.TokenId {
+		mw.returnCollectible(ctx, out)
+		return
+	}
+	if so.Counterparty == uuid.Nil || so.Counterparty.String() == out.Senders[0] {
+		mw.returnCollectible(ctx, out)
+		return
+	}
+
+	old, err := mw.store.ReadSwap(out.OutputId)
+	if err != nil {
+		panic(err)
+	} else if old != nil {
+		return
+	}
+	s := &Swap{
+		Id:           out.OutputId,
+		Collection:   token.Collection,
+		Token:        token.Key,
+		TokenId:      out.TokenId,
+		Owner:        out.Senders[0],
+		Counterparty: so.Counterparty.String(),
+		Want:         so.Want.String(),
+		State:        SwapStateActive,
+		ExpiredAt:    expiry,
+		CreatedAt:    out.CreatedAt,
+		UpdatedAt:    out.CreatedAt,
+	}
+
+	// an active swap record is escrowed until the MTG matches or expires it,
+	// so the match never depends on the local signing progress of outputs
+	m, err := mw.store.ReadActiveSwap(s.Counterparty, s.Want, s.Owner, s.TokenId)
+	if err != nil {
+		panic(err)
+	}
+	if m == nil || !m.ExpiredAt.After(out.CreatedAt) {
+		err = mw.store.WriteSwaps([]*Swap{s}, nil)
+		if err != nil {
+			panic(err)
+		}
+		slog.Info("MarketWorker.swap", "swap", s.Id, "token", s.TokenId, "owner", s.Owner, "want", s.Want)
+		return
+	}
+
+	mw.transferCollectible(ctx, m.Owner, s.TokenId, MemoSwap, mixin.UniqueConversationID(s.Id, "swap"))
+	mw.transferCollectible(ctx, s.Owner, m.TokenId, MemoSwap, mixin.UniqueConversationID(m.Id, "swap"))
+
+	s.State, m.State = SwapStateSwapped, SwapStateSwapped
+	s.Match, m.Match = m.Id, s.Id
+	s.UpdatedAt, m.UpdatedAt = out.CreatedAt, out.CreatedAt
+	events := []*nft.Event{
+		buildSwapEvent(s, m.Owner, out.CreatedAt),
+		buildSwapEvent(m, s.Owner, out.CreatedAt),
+	}
+	err = mw.store.WriteSwaps([]*Swap{s, m}, events)
+	if err != nil {
+		panic(err)
+	}
+	slog.Info("MarketWorker.swapped", "swap", s.Id, "match", m.Id, "token", s.TokenId, "want", s.Want)
+}
+
+func (mw *MarketWorker) expireSwap(ctx context.Context, s *Swap, now time.Time) {
+	traceId := mixin.UniqueConversationID(s.Id, "return")
+	mw.transferCollectible(ctx, s.Owner, s.TokenId, MemoReturn, traceId)
+
+	s.State = SwapStateExpired
+	s.UpdatedAt = now
+	err := mw.store.WriteSwaps([]*Swap{s}, nil)
+	if err != nil {
+		panic(err)
+	}
+	slog.Info("MarketWorker.expireSwap", "swap", s.Id, "token", s.TokenId, "trace", traceId)
+}
+
+func buildSwapEvent(s *Swap, receiver string, createdAt time.Time) *nft.Event {
+	return &nft.Event{
+		Id:         mixin.UniqueConversationID(s.Id, nft.EventSwap),
+		Kind:       nft.EventSwap,
+		Collection: uuid.FromBytesOrNil(s.Collection).String(),
+		Token:      hex.EncodeToString(s.Token),
+		TokenId:    s.TokenId,
+		User:       receiver,
+		TraceId:    mixin.UniqueConversationID(s.Id, "swap"),
+		CreatedAt:  createdAt,
+	}
+}
@@ -0,0 +1,260 @@
+package market
+
+import (
+	"context"
+	"encoding/hex"
+	"log/slog"
+	"time"
+
+	"github.com/MixinNetwork/nfo/nft"
+	"github.com/MixinNetwork/trusted-group/mtg"
+	"github.com/fox-one/mixin-sdk-go"
+	"github.com/gofrs/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	MemoRefund  = "REFUND"
+	MemoRelease = "RELEASE"
+	MemoReturn  = "RETURN"
+	MemoSwap    = "SWAP"
+
+	expiredBatchSize = 16
+)
+
+// MarketWorker settles the NFT sales escrowed by the MTG, all the deadlines
+// are checked against the output timestamps instead of the wall clock, so
+// that all members reach the same result
+type MarketWorker struct {
+	grp   *mtg.Group
+	store Store
+}
+
+func NewMarketWorker(grp *mtg.Group, store Store) *MarketWorker {
+	return &MarketWorker{
+		grp:   grp,
+		store: store,
+	}
+}
+
+func (mw *MarketWorker) ProcessOutput(ctx context.Context, out *mtg.Output) {
+	slog.Debug("MarketWorker.ProcessOutput", "utxo", out.UTXOID, "asset", out.AssetID, "sender", out.Sender, "amount", out.Amount.String())
+	mw.expire(ctx, out.CreatedAt)
+	if uuid.FromStringOrNil(out.Sender).String() == uuid.Nil.String() {
+		return
+	}
+	op, err := nft.DecodeOperationMemo(out.Memo)
+	if err != nil {
+		return
+	}
+
+	switch op.Purpose {
+	case nft.OperationPurposeBuy:
+		var bo BuyOperation
+		if op.Unmarshal(&bo) != nil {
+			mw.refund(ctx, out, "memo")
+			return
+		}
+		mw.processBuy(ctx, out, &bo)
+	case nft.OperationPurposeCancelList:
+		var co CancelListOperation
+		if op.Unmarshal(&co) != nil {
+			mw.refund(ctx, out, "memo")
+			return
+		}
+		mw.processCancelList(ctx, out, &co)
+	case nft.OperationPurposeBid:
+		var bo BidOperation
+		if op.Unmarshal(&bo) != nil {
+			mw.refund(ctx, out, "memo")
+			return
+		}
+		mw.processBid(ctx, out, &bo)
+	case nft.OperationPurposeOffer:
+		var oo OfferOperation
+		if op.Unmarshal(&oo) != nil {
+			mw.refund(ctx, out, "memo")
+			return
+		}
+		mw.processOffer(ctx, out, &oo)
+	case nft.OperationPurposeCancelOffer:
+		var co CancelOfferOperation
+		if op.Unmarshal(&co) != nil {
+			mw.refund(ctx, out, "memo")
+			return
+		}
+		mw.processCancelOffer(ctx, out, &co)
+	}
+}
+
+func (mw *MarketWorker) ProcessCollectibleOutput(ctx context.Context, out *mtg.CollectibleOutput) {
+	slog.Debug("MarketWorker.ProcessCollectibleOutput", "output", out.OutputId, "token", out.TokenId, "senders", out.Senders)
+	mw.expire(ctx, out.CreatedAt)
+	if out.State != mtg.OutputStateUnspent || len(out.Senders) == 0 {
+		return
+	}
+	op, err := nft.DecodeCollectibleOperationMemo(out.Memo)
+	if err != nil {
+		return
+	}
+
+	switch op.Purpose {
+	case nft.OperationPurposeList:
+		var lo ListOperation
+		if op.Unmarshal(&lo) != nil {
+			mw.returnCollectible(ctx, out)
+			return
+		}
+		mw.processList(ctx, out, &lo)
+	case nft.OperationPurposeAuction:
+		var ao AuctionOperation
+		if op.Unmarshal(&ao) != nil {
+			mw.returnCollectible(ctx, out)
+			return
+		}
+		mw.processAuction(ctx, out, &ao)
+	case nft.OperationPurposeAccept:
+		var ao AcceptOperation
+		if op.Unmarshal(&ao) != nil {
+			mw.returnCollectible(ctx, out)
+			return
+		}
+		mw.processAccept(ctx, out, &ao)
+	case nft.OperationPurposeSwap:
+		var so SwapOperation
+		if op.Unmarshal(&so) != nil {
+			mw.returnCollectible(ctx, out)
+			return
+		}
+		mw.processSwap(ctx, out, &so)
+	}
+}
+
+func (mw *MarketWorker) expire(ctx context.Context, now time.Time) {
+	listings, err := mw.store.ListExpiredListings(now, expiredBatchSize)
+	if err != nil {
+		panic(err)
+	}
+	for _, l := range listings {
+		mw.expireListing(ctx, l, now)
+	}
+
+	auctions, err := mw.store.ListEndedAuctions(now, expiredBatchSize)
+	if err != nil {
+		panic(err)
+	}
+	for _, a := range auctions {
+		mw.settleAuction(ctx, a, now)
+	}
+
+	offers, err := mw.store.ListExpiredOffers(now, expiredBatchSize)
+	if err != nil {
+		panic(err)
+	}
+	for _, o := range offers {
+		mw.expireOffer(ctx, o, now)
+	}
+
+	swaps, err := mw.store.ListExpiredSwaps(now, expiredBatchSize)
+	if err != nil {
+		panic(err)
+	}
+	for _, s := range swaps {
+		mw.expireSwap(ctx, s, now)
+	}
+}
+
+// readDepositToken verifies the NFO of the memo is exactly the deposited
+// collectible, and the token is minted by the MTG and not burned
+func (mw *MarketWorker) readDepositToken(out *mtg.CollectibleOutput, collection uuid.UUID, token []byte) *nft.Token {
+	if len(out.Senders) != 1 || out.SendersThreshold != 1 {
+		return nil
+	}
+	if nft.BuildTokenId(collection, token) != out.TokenId {
+		return nil
+	}
+	t, err := mw.store.ReadMintToken(collection.Bytes(), token)
+	if err != nil {
+		panic(err)
+	}
+	if t == nil || t.Burned {
+		return nil
+	}
+	return t
+}
+
+func (mw *MarketWorker) readCollection(collection []byte) *nft.Collection {
+	og, err := mw.store.ReadMintCollection(collection)
+	if err != nil {
+		panic(err)
+	}
+	return og
+}
+
+// returnCollectible sends the deposited collectible back to its senders
+func (mw *MarketWorker) returnCollectible(ctx context.Context, out *mtg.CollectibleOutput) {
+	traceId := mixin.UniqueConversationID(out.OutputId, "return")
+	threshold := int(out.SendersThreshold)
+	err := mw.grp.BuildCollectibleTransferTransaction(ctx, out.Senders, threshold, MemoReturn, out.TokenId, traceId)
+	if err != nil {
+		panic(err)
+	}
+	slog.Info("MarketWorker.returnCollectible", "output", out.OutputId, "token", out.TokenId, "trace", traceId)
+}
+
+func (mw *MarketWorker) transferCollectible(ctx context.Context, receiver, tokenId, memo, traceId string) {
+	err := mw.grp.BuildCollectibleTransferTransaction(ctx, []string{receiver}, 1, memo, tokenId, traceId)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (mw *MarketWorker) refund(ctx context.Context, out *mtg.Output, reason string) {
+	mw.refundPayment(ctx, out.UTXOID, out.Sender, out.AssetID, out.Amount.String(), reason, out.CreatedAt)
+}
+
+// refundPayment refunds the payment of the output id to the sender
+func (mw *MarketWorker) refundPayment(ctx context.Context, id, sender, assetId, amount, reason string, createdAt time.Time) {
+	traceId := mixin.UniqueConversationID(id, "refund")
+	err := mw.grp.BuildTransaction(ctx, assetId, []string{sender}, 1, amount, MemoRefund, traceId, "")
+	if err != nil {
+		panic(err)
+	}
+	err = mw.store.WriteEvent(&nft.Event{
+		Id:        mixin.UniqueConversationID(id, nft.EventRefund),
+		Kind:      nft.EventRefund,
+		User:      sender,
+		AssetId:   assetId,
+		Amount:    amount,
+		TraceId:   traceId,
+		Reason:    reason,
+		CreatedAt: createdAt,
+	})
+	if err != nil {
+		panic(err)
+	}
+	slog.Info("MarketWorker.refund", "utxo", id, "trace", traceId, "sender", sender, "reason", reason)
+}
+
+func buildSaleEvent(id string, collection, token []byte, tokenId, buyer, assetId string, price decimal.Decimal, traceId string, createdAt time.Time) *nft.Event {
+	return &nft.Event{
+		Id:         mixin.UniqueConversationID(id, nft.EventSale),
+		Kind:       nft.EventSale,
+		Collection: uuid.FromBytesOrNil(collection).String(),
+		Token:      hex.EncodeToString(token),
+		TokenId:    tokenId,
+		User:       buyer,
+		AssetId:    assetId,
+		Amount:     price.String(),
+		TraceId:    traceId,
+		CreatedAt:  createdAt,
+	}
+}
+
+func parsePrice(s string) (decimal.Decimal, bool) {
+	price, err := decimal.NewFromString(s)
+	if err != nil || !price.IsPositive() {
+		return decimal.Zero, false
+	}
+	return price, price.Equal(price.Truncate(8))
+}
@@ -3,18 +3,22 @@ package main
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/base64"
 	"fmt"
+	"log/slog"
 	"math/big"
 	"math/rand"
+	"net"
+	"sync/atomic"
 	"time"
 
 	"github.com/MixinNetwork/mixin/crypto"
-	"github.com/MixinNetwork/mixin/logger"
 	"github.com/MixinNetwork/trusted-group/mtg"
 	"github.com/MixinNetwork/nfo/nft"
 	"github.com/fox-one/mixin-sdk-go"
 	"github.com/gofrs/uuid"
+	"github.com/gorilla/websocket"
 	"github.com/shopspring/decimal"
 )
 
@@ -24,11 +28,13 @@ const (
 
 // Messenger is a simple MTG worker demo, it also sends some cmd to MM
 type MessengerWorker struct {
-	client *mixin.Client
-	grp    *mtg.Group
+	client    *mixin.Client
+	grp       *mtg.Group
+	store     nft.Store
+	connected atomic.Bool
 }
 
-func NewMessengerWorker(ctx context.Context, grp *mtg.Group, conf *mtg.Configuration) *MessengerWorker {
+func NewMessengerWorker(ctx context.Context, grp *mtg.Group, conf *mtg.Configuration, store nft.Store) *MessengerWorker {
 	s := &mixin.Keystore{
 		ClientID:   conf.App.ClientId,
 		SessionID:  conf.App.SessionId,
@@ -43,6 +49,7 @@ func NewMessengerWorker(ctx context.Context, grp *mtg.Group, conf *mtg.Configura
 	rw := &MessengerWorker{
 		client: client,
 		grp:    grp,
+		store:  store,
 	}
 	go rw.loop(ctx)
 	return rw
@@ -52,6 +59,9 @@ func (rw *MessengerWorker) ProcessOutput(ctx context.Context, out *mtg.Output) {
 	if out.Sender == "" || out.AssetID != CNBAssetID {
 		return
 	}
+	if _, err := nft.DecodeOperationMemo(out.Memo); err == nil {
+		return
+	}
 	receivers := []string{out.Sender}
 	memo := "REFUND#" + out.Amount.String()
 	traceId := mixin.UniqueConversationID(out.UTXOID, "refund")
@@ -60,23 +70,74 @@ func (rw *MessengerWorker) ProcessOutput(ctx context.Context, out *mtg.Output) {
 	if err != nil {
 		panic(err)
 	}
+	err = rw.store.WriteEvent(&nft.Event{
+		Id:        mixin.UniqueConversationID(out.UTXOID, nft.EventRefund),
+		Kind:      nft.EventRefund,
+		User:      out.Sender,
+		AssetId:   out.AssetID,
+		Amount:    amount,
+		TraceId:   traceId,
+		CreatedAt: out.CreatedAt,
+	})
+	if err != nil {
+		panic(err)
+	}
 }
 
 func (rw *MessengerWorker) ProcessCollectibleOutput(ctx context.Context, out *mtg.CollectibleOutput) {
-	logger.Verbosef("MessengerWorker.ProcessCollectibleOutput(%v)\n", *out)
+	slog.Debug("MessengerWorker.ProcessCollectibleOutput", "output", out.OutputId, "token", out.TokenId, "senders", out.Senders)
 }
 
 func (rw *MessengerWorker) loop(ctx context.Context) {
 	for {
-		err := rw.client.LoopBlaze(context.Background(), rw)
-		logger.Printf("LoopBlaze() => %v\n", err)
+		err := rw.client.LoopBlaze(context.Background(), rw, rw.dialBlaze)
+		rw.connected.Store(false)
+		slog.Warn("MessengerWorker.LoopBlaze", "error", err)
 		if ctx.Err() != nil {
 			break
 		}
+		blazeReconnects.Inc()
 		time.Sleep(3 * time.Second)
 	}
 }
 
+// Connected reports whether the messenger is connected to Blaze
+func (rw *MessengerWorker) Connected() bool {
+	return rw.connected.Load()
+}
+
+// dialBlaze marks the messenger connected once Blaze accepts the websocket
+// handshake, whose response is the first read from the TLS connection
+func (rw *MessengerWorker) dialBlaze(dialer *websocket.Dialer) {
+	dialer.NetDialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		d := &tls.Dialer{Config: &tls.Config{ServerName: host}}
+		conn, err := d.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		return &blazeConn{Conn: conn, rw: rw}, nil
+	}
+}
+
+type blazeConn struct {
+	net.Conn
+	rw       *MessengerWorker
+	answered bool
+}
+
+func (c *blazeConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if !c.answered && n > 0 {
+		c.answered = true
+		c.rw.connected.Store(bytes.HasPrefix(b[:n], []byte("HTTP/1.1 101")))
+	}
+	return n, err
+}
+
 func (rw *MessengerWorker) OnMessage(ctx context.Context, msg *mixin.MessageView, userId string) error {
 	tt := "%s mixin://codes/%s"
 	if msg.Category == mixin.MessageCategoryPlainSticker {
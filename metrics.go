@@ -0,0 +1,107 @@
+package main
+
+import (
+	"log/slog"
+
+	"github.com/MixinNetwork/nfo/store"
+	"github.com/MixinNetwork/trusted-group/mtg"
+	"github.com/fox-one/mixin-sdk-go"
+	"github.com/gofrs/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	blazeReconnects = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "nfo",
+		Name:      "blaze_reconnects_total",
+		Help:      "Number of times the messenger reconnected to Blaze.",
+	})
+
+	circulationDesc = prometheus.NewDesc("nfo_collection_circulation",
+		"Number of tokens minted in the collection.", []string{"collection"}, nil)
+	burnedDesc = prometheus.NewDesc("nfo_collection_burned",
+		"Number of tokens burned in the collection.", []string{"collection"}, nil)
+	mintersDesc = prometheus.NewDesc("nfo_collection_minters",
+		"Number of unique users minted in the collection.", []string{"collection"}, nil)
+	salesDesc = prometheus.NewDesc("nfo_collection_sales",
+		"Number of market sales in the collection.", []string{"collection"}, nil)
+	outputsDesc = prometheus.NewDesc("nfo_outputs",
+		"Number of group outputs by state and asset.", []string{"state", "asset"}, nil)
+	transactionsDesc = prometheus.NewDesc("nfo_transactions",
+		"Number of group transactions by state and type.", []string{"state", "type"}, nil)
+	badgerSizeDesc = prometheus.NewDesc("nfo_badger_size_bytes",
+		"Size of the Badger database files.", []string{"type"}, nil)
+)
+
+var transactionStates = map[int]string{
+	mtg.TransactionStateInitial:  "initial",
+	mtg.TransactionStateSigning:  "signing",
+	mtg.TransactionStateSigned:   "signed",
+	mtg.TransactionStateSnapshot: "snapshot",
+}
+
+// StoreCollector reads the gauges from the store indexes on every scrape
+type StoreCollector struct {
+	store *store.BadgerStore
+}
+
+func NewStoreCollector(store *store.BadgerStore) *StoreCollector {
+	return &StoreCollector{store: store}
+}
+
+func (sc *StoreCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- circulationDesc
+	ch <- burnedDesc
+	ch <- mintersDesc
+	ch <- salesDesc
+	ch <- outputsDesc
+	ch <- transactionsDesc
+	ch <- badgerSizeDesc
+}
+
+func (sc *StoreCollector) Collect(ch chan<- prometheus.Metric) {
+	lsm, vlog := sc.store.Size()
+	ch <- prometheus.MustNewConstMetric(badgerSizeDesc, prometheus.GaugeValue, float64(lsm), "lsm")
+	ch <- prometheus.MustNewConstMetric(badgerSizeDesc, prometheus.GaugeValue, float64(vlog), "vlog")
+
+	cs, err := sc.store.ListMintCollections()
+	if err != nil {
+		slog.Error("StoreCollector.ListMintCollections", "error", err)
+	}
+	for _, c := range cs {
+		id := uuid.FromBytesOrNil(c.Key).String()
+		ch <- prometheus.MustNewConstMetric(circulationDesc, prometheus.GaugeValue, float64(c.Circulation), id)
+		stats, err := sc.store.ReadCollectionStats(c.Key)
+		if err != nil {
+			slog.Error("StoreCollector.ReadCollectionStats", "collection", id, "error", err)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(burnedDesc, prometheus.GaugeValue, float64(stats.Burned), id)
+		ch <- prometheus.MustNewConstMetric(mintersDesc, prometheus.GaugeValue, float64(stats.Minters), id)
+		ch <- prometheus.MustNewConstMetric(salesDesc, prometheus.GaugeValue, float64(stats.Sales), id)
+	}
+
+	for _, state := range []string{mixin.UTXOStateUnspent, mixin.UTXOStateSigned, mixin.UTXOStateSpent} {
+		assets, err := sc.store.CountOutputsForState(state)
+		if err != nil {
+			slog.Error("StoreCollector.CountOutputsForState", "state", state, "error", err)
+		}
+		for asset, count := range assets {
+			ch <- prometheus.MustNewConstMetric(outputsDesc, prometheus.GaugeValue, float64(count), state, asset)
+		}
+	}
+
+	for state, name := range transactionStates {
+		count, err := sc.store.CountTransactions(state)
+		if err != nil {
+			slog.Error("StoreCollector.CountTransactions", "state", name, "error", err)
+		}
+		ch <- prometheus.MustNewConstMetric(transactionsDesc, prometheus.GaugeValue, float64(count), name, "multisig")
+		count, err = sc.store.CountCollectibleTransactions(state)
+		if err != nil {
+			slog.Error("StoreCollector.CountCollectibleTransactions", "state", name, "error", err)
+		}
+		ch <- prometheus.MustNewConstMetric(transactionsDesc, prometheus.GaugeValue, float64(count), name, "collectible")
+	}
+}
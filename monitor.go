@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/MixinNetwork/nfo/store"
+	"github.com/MixinNetwork/trusted-group/mtg"
+)
+
+// the group writes the property after draining the outputs from the
+// network in each loop, and resets it when the group is built
+const groupBootSynced = "group-boot-synced"
+
+// MonitorWorker should be the last worker added to the group, so that it
+// records when the group has finished processing an output
+type MonitorWorker struct {
+	sync.RWMutex
+	processedAt time.Time
+	outputAt    time.Time
+	syncedAt    time.Time
+}
+
+func NewMonitorWorker() *MonitorWorker {
+	return &MonitorWorker{}
+}
+
+func (mw *MonitorWorker) ProcessOutput(ctx context.Context, out *mtg.Output) {
+	mw.record(out.CreatedAt)
+}
+
+func (mw *MonitorWorker) ProcessCollectibleOutput(ctx context.Context, out *mtg.CollectibleOutput) {
+	mw.record(out.CreatedAt)
+}
+
+// LastProcessed returns the time when the last output was processed
+// and the created time of that output
+func (mw *MonitorWorker) LastProcessed() (time.Time, time.Time) {
+	mw.RLock()
+	defer mw.RUnlock()
+
+	return mw.processedAt, mw.outputAt
+}
+
+// LastSynced returns the time when the group last drained the outputs from
+// the network, and it's zero until the first drain since the node started
+func (mw *MonitorWorker) LastSynced() time.Time {
+	mw.RLock()
+	defer mw.RUnlock()
+
+	return mw.syncedAt
+}
+
+// Store wraps the store of the group to record its sync progress in memory
+func (mw *MonitorWorker) Store(bs *store.BadgerStore) mtg.Store {
+	return &monitorStore{BadgerStore: bs, mon: mw}
+}
+
+type monitorStore struct {
+	*store.BadgerStore
+	mon *MonitorWorker
+}
+
+func (ms *monitorStore) WriteProperty(key, val []byte) error {
+	err := ms.BadgerStore.WriteProperty(key, val)
+	if err == nil && string(key) == groupBootSynced && bytes.Equal(val, []byte{1}) {
+		ms.mon.Lock()
+		ms.mon.syncedAt = time.Now()
+		ms.mon.Unlock()
+	}
+	return err
+}
+
+func (mw *MonitorWorker) record(createdAt time.Time) {
+	mw.Lock()
+	defer mw.Unlock()
+
+	mw.processedAt = time.Now()
+	mw.outputAt = createdAt
+}
@@ -0,0 +1,163 @@
+package nft
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+
+	"github.com/MixinNetwork/trusted-group/mtg"
+	"github.com/fox-one/mixin-sdk-go"
+	"github.com/gofrs/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	RoyaltyMaximumRate       = 2500
+	RoyaltyMaximumRecipients = 8
+	RoyaltyShareTotal        = 10000
+)
+
+func (mw *MintWorker) processOperation(ctx context.Context, out *mtg.Output, op *Operation) {
+	if uuid.FromStringOrNil(out.Sender).String() == uuid.Nil.String() {
+		return
+	}
+	if op.Purpose == OperationPurposePurchase {
+		var po PurchaseOperation
+		if op.Unmarshal(&po) != nil {
+			mw.refund(ctx, out, "memo")
+			return
+		}
+		mw.processPurchase(ctx, out, &po)
+		return
+	}
+
+	min, err := decimal.NewFromString(MintMinimumCost)
+	if err != nil {
+		return
+	}
+	if out.AssetID != MintAssetId || out.Amount.Cmp(min) < 0 {
+		return
+	}
+
+	switch op.Purpose {
+	case OperationPurposeRoyalty:
+		var ro RoyaltyOperation
+		if op.Unmarshal(&ro) == nil {
+			mw.processRoyalty(out, &ro)
+		}
+	case OperationPurposeSale:
+		var so SaleOperation
+		if op.Unmarshal(&so) == nil {
+			mw.processSale(out, &so)
+		}
+	case OperationPurposeMetadata:
+		var mo MetadataOperation
+		if op.Unmarshal(&mo) == nil {
+			mw.processMetadata(out, &mo)
+		}
+	case OperationPurposeGrant, OperationPurposeRevoke:
+		var mo MinterOperation
+		if op.Unmarshal(&mo) == nil {
+			mw.processMinter(out, &mo, op.Purpose == OperationPurposeGrant)
+		}
+	case OperationPurposePolicy:
+		var po PolicyOperation
+		if op.Unmarshal(&po) == nil {
+			mw.processPolicy(out, &po)
+		}
+	case OperationPurposeLimit:
+		var lo LimitOperation
+		if op.Unmarshal(&lo) == nil {
+			mw.processLimit(out, &lo)
+		}
+	case OperationPurposeOption:
+		var oo OptionOperation
+		if op.Unmarshal(&oo) == nil {
+			mw.processOption(out, &oo)
+		}
+	case OperationPurposeMint:
+		var mo MintOperation
+		if op.Unmarshal(&mo) == nil {
+			mw.processSequenceMint(ctx, out, &mo)
+		}
+	}
+}
+
+func (mw *MintWorker) processRoyalty(out *mtg.Output, ro *RoyaltyOperation) {
+	royalty := &Royalty{Rate: ro.Rate}
+	if ro.Rate < 0 || ro.Rate > RoyaltyMaximumRate {
+		return
+	}
+	if ro.Rate > 0 {
+		if len(ro.Recipients) == 0 || len(ro.Recipients) > RoyaltyMaximumRecipients {
+			return
+		}
+		var total int
+		filter := make(map[uuid.UUID]bool)
+		for _, r := range ro.Recipients {
+			if r.User == uuid.Nil || filter[r.User] || r.Share <= 0 {
+				return
+			}
+			filter[r.User] = true
+			total += r.Share
+			royalty.Recipients = append(royalty.Recipients, &RoyaltyRecipient{
+				UserId: r.User.String(),
+				Share:  r.Share,
+			})
+		}
+		if total != RoyaltyShareTotal {
+			return
+		}
+	} else {
+		royalty = nil
+	}
+
+	og, events := mw.readCreatorCollection(out, ro.Collection)
+	if og == nil {
+		return
+	}
+	og.Royalty = royalty
+	err := mw.store.WriteMintCollection(og, events)
+	if err != nil {
+		panic(err)
+	}
+	slog.Info("MintWorker.royalty", "utxo", out.UTXOID, "collection", ro.Collection.String(), "rate", ro.Rate, "sender", out.Sender)
+}
+
+// readCreatorCollection returns the collection of the creator, starts a new
+// one, or returns the multisig collection once the operation is approved
+func (mw *MintWorker) readCreatorCollection(out *mtg.Output, collection uuid.UUID) (*Collection, []*Event) {
+	ck := collection.Bytes()
+	if bytes.Compare(ck, mtg.NMDefaultCollectionKey) == 0 {
+		return nil, nil
+	}
+	og, err := mw.store.ReadMintCollection(ck)
+	if err != nil {
+		panic(err)
+	}
+	if og != nil && og.IsMultisig() {
+		approval := mw.approve(out, og)
+		if approval == nil {
+			return nil, nil
+		}
+		return og, []*Event{approval}
+	}
+	if og != nil && og.Creator != out.Sender {
+		return nil, nil
+	}
+	if og != nil {
+		return og, nil
+	}
+	og = &Collection{Key: ck, Creator: out.Sender}
+	return og, []*Event{buildCollectionEvent(out, collection)}
+}
+
+func buildCollectionEvent(out *mtg.Output, collection uuid.UUID) *Event {
+	return &Event{
+		Id:         mixin.UniqueConversationID(out.UTXOID, EventCollection),
+		Kind:       EventCollection,
+		Collection: collection.String(),
+		User:       out.Sender,
+		CreatedAt:  out.CreatedAt,
+	}
+}
@@ -0,0 +1,196 @@
+package nft
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/MixinNetwork/trusted-group/mtg"
+	"github.com/gofrs/uuid"
+)
+
+// The mint NFO extra is the optional content hash, which may be followed by
+// fields encoded as tag || length || value, and the MTG mints the NFO with
+// only the hash as its extra.
+
+const (
+	MintExtraTagReceivers = 1
+	MintExtraTagTraits    = 2
+
+	MintTraitsMaximumSize    = 64
+	MintReceiversMaximumSize = 4
+)
+
+type MintExtra struct {
+	Hash      []byte
+	Receivers []string
+	Threshold int
+	Traits    []*Trait
+}
+
+// BuildMintNFO returns the mint NFO of the token with the content hash
+func BuildMintNFO(collection string, token []byte, hash crypto.Hash) []byte {
+	return mtg.BuildMintNFO(collection, token, hash)
+}
+
+// BuildMintNFOWithReceivers returns the mint NFO of the token, and the NFT
+// is sent to the receivers instead of the payer
+func BuildMintNFOWithReceivers(collection string, token []byte, hash crypto.Hash, receivers []string, threshold int) []byte {
+	me := &MintExtra{Hash: hash[:], Receivers: receivers, Threshold: threshold}
+	return BuildMintNFOWithExtra(collection, token, me)
+}
+
+// BuildMintNFOWithExtra returns the mint NFO of the token with the optional
+// receivers and traits of the extra
+func BuildMintNFOWithExtra(collection string, token []byte, me *MintExtra) []byte {
+	var hash crypto.Hash
+	copy(hash[:], me.Hash)
+	nfm, err := mtg.DecodeNFOMemo(mtg.BuildMintNFO(collection, token, hash))
+	if err != nil {
+		panic(err)
+	}
+	nfm.Extra = me.Encode()
+	return nfm.Encode()
+}
+
+// Encode panics if the extra exceeds the limits of the decoder, so that an
+// invalid extra is never built
+func (me *MintExtra) Encode() []byte {
+	var hash crypto.Hash
+	copy(hash[:], me.Hash)
+	extra := hash[:]
+	if len(me.Receivers) > 0 {
+		if len(me.Receivers) > MintReceiversMaximumSize {
+			panic(fmt.Errorf("mint extra receivers %d", len(me.Receivers)))
+		}
+		value := []byte{byte(me.Threshold)}
+		for _, r := range me.Receivers {
+			value = append(value, uuid.Must(uuid.FromString(r)).Bytes()...)
+		}
+		extra = appendMintExtraField(extra, MintExtraTagReceivers, value)
+	}
+	if len(me.Traits) > 0 {
+		var value []byte
+		for _, t := range me.Traits {
+			value = appendTraitString(value, t.Key)
+			value = appendTraitString(value, t.Value)
+		}
+		if len(value) > MintTraitsMaximumSize {
+			panic(fmt.Errorf("mint extra traits %d", len(value)))
+		}
+		extra = appendMintExtraField(extra, MintExtraTagTraits, value)
+	}
+	return extra
+}
+
+func appendMintExtraField(extra []byte, tag byte, value []byte) []byte {
+	if len(value) > 255 {
+		panic(fmt.Errorf("mint extra tag %d size %d", tag, len(value)))
+	}
+	extra = append(extra, tag, byte(len(value)))
+	return append(extra, value...)
+}
+
+func appendTraitString(value []byte, s string) []byte {
+	if len(s) == 0 || len(s) > 255 {
+		panic(fmt.Errorf("mint extra trait %s", s))
+	}
+	value = append(value, byte(len(s)))
+	return append(value, s...)
+}
+
+// DecodeMintExtra decodes the extra of the mint NFO, and the receivers are
+// empty unless specified
+func DecodeMintExtra(extra []byte) (*MintExtra, error) {
+	me := &MintExtra{Hash: extra}
+	if len(extra) <= len(crypto.Hash{}) {
+		return me, nil
+	}
+	me.Hash = extra[:len(crypto.Hash{})]
+
+	r := bytes.NewReader(extra[len(me.Hash):])
+	for r.Len() > 0 {
+		tag, _ := r.ReadByte()
+		l, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		value := make([]byte, l)
+		n, _ := r.Read(value)
+		if n != len(value) {
+			return nil, fmt.Errorf("mint extra tag %d short %d %d", tag, n, l)
+		}
+		switch tag {
+		case MintExtraTagReceivers:
+			err = me.decodeReceivers(value)
+		case MintExtraTagTraits:
+			err = me.decodeTraits(value)
+		default:
+			err = fmt.Errorf("mint extra tag %d unknown", tag)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return me, nil
+}
+
+func (me *MintExtra) decodeReceivers(value []byte) error {
+	if me.Receivers != nil || len(value) < 17 || (len(value)-1)%16 != 0 {
+		return fmt.Errorf("mint extra receivers %x", value)
+	}
+	if (len(value)-1)/16 > MintReceiversMaximumSize {
+		return fmt.Errorf("mint extra receivers %x", value)
+	}
+	me.Threshold = int(value[0])
+	filter := make(map[string]bool)
+	for i := 1; i < len(value); i += 16 {
+		r := uuid.FromBytesOrNil(value[i : i+16])
+		if r == uuid.Nil || filter[r.String()] {
+			return fmt.Errorf("mint extra receiver %s", r.String())
+		}
+		filter[r.String()] = true
+		me.Receivers = append(me.Receivers, r.String())
+	}
+	if me.Threshold < 1 || me.Threshold > len(me.Receivers) {
+		return fmt.Errorf("mint extra threshold %d/%d", me.Threshold, len(me.Receivers))
+	}
+	return nil
+}
+
+func (me *MintExtra) decodeTraits(value []byte) error {
+	if me.Traits != nil || len(value) == 0 || len(value) > MintTraitsMaximumSize {
+		return fmt.Errorf("mint extra traits %x", value)
+	}
+	filter := make(map[string]bool)
+	for r := bytes.NewReader(value); r.Len() > 0; {
+		key, err := readTraitString(r)
+		if err != nil {
+			return err
+		}
+		val, err := readTraitString(r)
+		if err != nil {
+			return err
+		}
+		if filter[key] {
+			return fmt.Errorf("mint extra trait %s duplicated", key)
+		}
+		filter[key] = true
+		me.Traits = append(me.Traits, &Trait{Key: key, Value: val})
+	}
+	return nil
+}
+
+func readTraitString(r *bytes.Reader) (string, error) {
+	l, err := r.ReadByte()
+	if err != nil || l == 0 {
+		return "", fmt.Errorf("mint extra trait length %d %v", l, err)
+	}
+	b := make([]byte, l)
+	n, _ := r.Read(b)
+	if n != len(b) || !utf8.Valid(b) {
+		return "", fmt.Errorf("mint extra trait %x", b)
+	}
+	return string(b), nil
+}
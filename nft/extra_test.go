@@ -0,0 +1,105 @@
+package nft
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"strings"
+	"testing"
+)
+
+func TestMintExtraCodec(t *testing.T) {
+	hash := sha256.Sum256([]byte("content"))
+	receivers := []string{
+		"4b188942-9fb0-4b99-b4be-e741a06d1ebf",
+		"dd655520-c919-4349-822f-af92fabdbdf4",
+		"c6d0c728-2624-429b-8e0d-d9d19b6592fa",
+	}
+	traits := []*Trait{{"color", "red"}, {"size", "large"}}
+
+	tests := []struct {
+		name  string
+		extra *MintExtra
+	}{
+		{"hash only", &MintExtra{Hash: hash[:]}},
+		{"receivers", &MintExtra{Hash: hash[:], Receivers: receivers, Threshold: 2}},
+		{"single receiver", &MintExtra{Hash: hash[:], Receivers: receivers[:1], Threshold: 1}},
+		{"traits", &MintExtra{Hash: hash[:], Traits: traits}},
+		{"receivers and traits", &MintExtra{Hash: hash[:], Receivers: receivers, Threshold: 3, Traits: traits}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			me, err := DecodeMintExtra(tc.extra.Encode())
+			if err != nil {
+				t.Fatalf("DecodeMintExtra %v", err)
+			}
+			if !bytes.Equal(me.Hash, tc.extra.Hash) {
+				t.Fatalf("hash %x, want %x", me.Hash, tc.extra.Hash)
+			}
+			if me.Threshold != tc.extra.Threshold {
+				t.Fatalf("threshold %d, want %d", me.Threshold, tc.extra.Threshold)
+			}
+			if strings.Join(me.Receivers, ",") != strings.Join(tc.extra.Receivers, ",") {
+				t.Fatalf("receivers %v, want %v", me.Receivers, tc.extra.Receivers)
+			}
+			if len(me.Traits) != len(tc.extra.Traits) {
+				t.Fatalf("traits %d, want %d", len(me.Traits), len(tc.extra.Traits))
+			}
+			for i, tr := range me.Traits {
+				if *tr != *tc.extra.Traits[i] {
+					t.Fatalf("trait %v, want %v", *tr, *tc.extra.Traits[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeMintExtraInvalid(t *testing.T) {
+	hash := sha256.Sum256([]byte("content"))
+	receiver := func(b byte) []byte {
+		return bytes.Repeat([]byte{b}, 16)
+	}
+	field := func(tag byte, value ...[]byte) []byte {
+		v := bytes.Join(value, nil)
+		return append([]byte{tag, byte(len(v))}, v...)
+	}
+	trait := func(key, value string) []byte {
+		b := append([]byte{byte(len(key))}, key...)
+		b = append(b, byte(len(value)))
+		return append(b, value...)
+	}
+	extra := func(fields ...[]byte) []byte {
+		return append(hash[:], bytes.Join(fields, nil)...)
+	}
+
+	tests := []struct {
+		name  string
+		extra []byte
+	}{
+		{"unknown tag", extra(field(3, []byte{1}))},
+		{"missing length", extra([]byte{MintExtraTagReceivers})},
+		{"truncated value", extra([]byte{MintExtraTagReceivers, 17, 1}, receiver(1)[:8])},
+		{"receivers size", extra(field(MintExtraTagReceivers, []byte{1}, receiver(1)[:8]))},
+		{"nil receiver", extra(field(MintExtraTagReceivers, []byte{1}, receiver(0)))},
+		{"duplicate receiver", extra(field(MintExtraTagReceivers, []byte{1}, receiver(1), receiver(1)))},
+		{"zero threshold", extra(field(MintExtraTagReceivers, []byte{0}, receiver(1)))},
+		{"large threshold", extra(field(MintExtraTagReceivers, []byte{3}, receiver(1), receiver(2)))},
+		{"too many receivers", extra(field(MintExtraTagReceivers, []byte{1}, receiver(1), receiver(2), receiver(3), receiver(4), receiver(5)))},
+		{"repeated receivers", extra(field(MintExtraTagReceivers, []byte{1}, receiver(1)), field(MintExtraTagReceivers, []byte{1}, receiver(2)))},
+		{"empty traits", extra(field(MintExtraTagTraits))},
+		{"duplicate trait", extra(field(MintExtraTagTraits, trait("color", "red"), trait("color", "blue")))},
+		{"empty trait key", extra(field(MintExtraTagTraits, []byte{0, 3}, []byte("red")))},
+		{"empty trait value", extra(field(MintExtraTagTraits, trait("color", "")))},
+		{"truncated trait", extra(field(MintExtraTagTraits, []byte{5}, []byte("col")))},
+		{"invalid trait", extra(field(MintExtraTagTraits, trait("color", "\xff")))},
+		{"large traits", extra(field(MintExtraTagTraits, trait(strings.Repeat("k", 40), strings.Repeat("v", 40))))},
+		{"repeated traits", extra(field(MintExtraTagTraits, trait("a", "b")), field(MintExtraTagTraits, trait("c", "d")))},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := DecodeMintExtra(tc.extra)
+			if err == nil {
+				t.Fatalf("DecodeMintExtra %x, want error", tc.extra)
+			}
+		})
+	}
+}
@@ -1,18 +1,217 @@
 package nft
 
+import (
+	"bytes"
+	"time"
+
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/MixinNetwork/trusted-group/mtg"
+)
+
+const (
+	EventMint       = "mint"
+	EventBurn       = "burn"
+	EventRefund     = "refund"
+	EventCollection = "collection"
+	EventReceive    = "receive"
+	EventReject     = "reject"
+	EventSale       = "sale"
+	EventPurchase   = "purchase"
+	EventSwap       = "swap"
+	EventApproval   = "approval"
+)
+
 type Store interface {
-	WriteMintToken(collection []byte, id []byte, user string) error
+	WriteMintToken(collection []byte, id []byte, user string, createdAt time.Time, traits []*Trait, events []*Event) error
+	WriteDefaultMintToken(id []byte, user string, createdAt time.Time, traits []*Trait, quota *MintQuota, events []*Event) error
+	ReadMintQuota(user string) (*MintQuota, error)
+	WriteMintLimit(limit *MintLimit, events []*Event) error
+	ReadMintLimit(at time.Time) (*MintLimit, error)
+	WriteBurnToken(collection []byte, id []byte, event *Event) error
+	WriteMintCollection(og *Collection, events []*Event) error
 	ReadMintCollection(collection []byte) (*Collection, error)
+	ReadCollectionBySymbol(symbol string) (*Collection, error)
+	WriteMinterChange(og *Collection, change *MinterChange, events []*Event) error
+	WriteApproval(og *Collection, action, user string, createdAt, since time.Time) (int, error)
 	ReadMintToken(collection, token []byte) (*Token, error)
+	ReadTokenByTokenId(tokenId string) (*Token, error)
+	ListMintsByUser(user string, offset []byte, limit int) ([]*UserMint, []byte, error)
+	ReadCollectionStats(collection []byte) (*CollectionStats, error)
+	WriteSequenceToken(collection []byte, sequence uint64, id []byte, user, utxoId string, createdAt time.Time, events []*Event) error
+	ReadAssignedToken(utxoId string) ([]byte, error)
+
+	WriteMintSale(og *Collection, s *Sale, events []*Event) error
+	ReadMintSale(collection []byte) (*Sale, error)
+	ReadMintSaleCount(collection []byte, user string) (int, error)
+	WriteSaleToken(s *Sale, sequence uint64, id []byte, user, utxoId string, createdAt time.Time, events []*Event) error
+
+	WriteEvent(event *Event) error
 }
 
 type Collection struct {
 	Key         []byte
 	Creator     string
 	Circulation int
+	Burned      int
+	Royalty     *Royalty
+	Sequence    uint64
+	Sequential  bool
+	Minters     []string
+	Members     []string
+	Threshold   int
+
+	Name        string
+	Symbol      string
+	Description string
+	Icon        []byte
+	URL         string
+}
+
+// Sale is the public mint of a collection configured by the creator, the
+// buyers receive sequentially assigned token ids, and the root is the
+// optional merkle root of the allowlist
+type Sale struct {
+	Collection []byte
+	AssetId    string
+	Price      string
+	StartAt    time.Time
+	EndAt      time.Time
+	Limit      int
+	Supply     int
+	Root       []byte
+	Hash       []byte
+	Minted     int
+}
+
+func (s *Sale) ContentHash() crypto.Hash {
+	var hash crypto.Hash
+	copy(hash[:], s.Hash)
+	return hash
+}
+
+// CanMint checks whether the user is the creator, a granted minter, or a
+// member of the multisig collection
+func (og *Collection) CanMint(user string) bool {
+	if bytes.Compare(og.Key, mtg.NMDefaultCollectionKey) == 0 {
+		return true
+	}
+	if og.IsMultisig() {
+		return og.IsMember(user)
+	}
+	if og.Creator == user {
+		return true
+	}
+	for _, m := range og.Minters {
+		if m == user {
+			return true
+		}
+	}
+	return false
+}
+
+// IsMultisig checks whether the collection is controlled by the members
+func (og *Collection) IsMultisig() bool {
+	return len(og.Members) > 0
+}
+
+func (og *Collection) IsMember(user string) bool {
+	for _, m := range og.Members {
+		if m == user {
+			return true
+		}
+	}
+	return false
+}
+
+// Owners returns the receivers and threshold of the collection tokens
+func (og *Collection) Owners(user string) ([]string, int) {
+	if og.IsMultisig() {
+		return og.Members, og.Threshold
+	}
+	return []string{user}, 1
+}
+
+// CollectionStats totals the amounts as decimals by asset id
+type CollectionStats struct {
+	Collection  []byte
+	Minted      int
+	Burned      int
+	Minters     int
+	Sales       int
+	Purchases   int
+	FirstMintAt time.Time
+	LastMintAt  time.Time
+	Fees        map[string]string
+	Volume      map[string]string
+	Proceeds    map[string]string
+}
+
+// UserMint is a token paid by the user, who may not be the receiver
+type UserMint struct {
+	User       string
+	Collection []byte
+	Token      []byte
+	CreatedAt  time.Time
+}
+
+// MinterChange is a grant or revoke of the minting right by the creator
+type MinterChange struct {
+	Collection []byte
+	User       string
+	Granted    bool
+	Sender     string
+	OutputId   string
+	CreatedAt  time.Time
+}
+
+// Royalty is paid from every sale settled by the MTG, the rate and shares
+// are in basis points, and the shares of all recipients sum to 10000
+type Royalty struct {
+	Rate       int
+	Recipients []*RoyaltyRecipient
+}
+
+type RoyaltyRecipient struct {
+	UserId string
+	Share  int
 }
 
 type Token struct {
 	Collection []byte
 	Key        []byte
+	Burned     bool
+	Traits     []*Trait
+}
+
+// Trait is an attribute of the token attached at mint time, and indexed
+// by the MTG to query the tokens and their rarity in the collection
+type Trait struct {
+	Key   string
+	Value string
+}
+
+type TraitCount struct {
+	Key   string
+	Value string
+	Count int
+}
+
+// Event is appended to the event log with a monotonically increasing
+// sequence, the id must be deterministic so that the same event is only
+// written once even if an output is processed again
+type Event struct {
+	Sequence   uint64    `json:"sequence"`
+	Id         string    `json:"id"`
+	Kind       string    `json:"kind"`
+	Collection string    `json:"collection,omitempty"`
+	Token      string    `json:"token,omitempty"`
+	TokenId    string    `json:"token_id,omitempty"`
+	User       string    `json:"user,omitempty"`
+	AssetId    string    `json:"asset_id,omitempty"`
+	Amount     string    `json:"amount,omitempty"`
+	TraceId    string    `json:"trace_id,omitempty"`
+	Reason     string    `json:"reason,omitempty"`
+	Action     string    `json:"action,omitempty"`
+	Hash       string    `json:"hash,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
 }
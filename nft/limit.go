@@ -0,0 +1,83 @@
+package nft
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/MixinNetwork/trusted-group/mtg"
+)
+
+// LimitOperation is sent by the MTG members to limit the default collection
+type LimitOperation struct {
+	Interval int64 `msgpack:"I"`
+	Daily    int   `msgpack:"D"`
+	Activate int64 `msgpack:"A"`
+}
+
+// MintLimit applies to the default collection outputs from the activation
+type MintLimit struct {
+	Interval time.Duration
+	Daily    int
+	ActiveAt time.Time
+}
+
+// MintQuota is the default collection mints of the user in the UTC day
+type MintQuota struct {
+	User   string
+	Day    time.Time
+	Count  int
+	LastAt time.Time
+}
+
+func (mw *MintWorker) processLimit(out *mtg.Output, lo *LimitOperation) {
+	if lo.Interval < 0 || lo.Daily < 0 {
+		return
+	}
+	activeAt := time.Unix(lo.Activate, 0)
+	if activeAt.Before(out.CreatedAt) {
+		return
+	}
+	approval := mw.approve(out, &Collection{
+		Key:       mtg.NMDefaultCollectionKey,
+		Members:   mw.grp.GetMembers(),
+		Threshold: mw.grp.GetThreshold(),
+	})
+	if approval == nil {
+		return
+	}
+	limit := &MintLimit{
+		Interval: time.Duration(lo.Interval) * time.Second,
+		Daily:    lo.Daily,
+		ActiveAt: activeAt,
+	}
+	err := mw.store.WriteMintLimit(limit, []*Event{approval})
+	if err != nil {
+		panic(err)
+	}
+	slog.Info("MintWorker.limit", "utxo", out.UTXOID, "interval", limit.Interval,
+		"daily", limit.Daily, "active", limit.ActiveAt, "sender", out.Sender)
+}
+
+// Allow checks the quota against the limit active at the time
+func (q *MintQuota) Allow(limit *MintLimit, at time.Time) bool {
+	if q == nil || limit == nil {
+		return true
+	}
+	if limit.Interval > 0 && at.Sub(q.LastAt) < limit.Interval {
+		return false
+	}
+	return limit.Daily == 0 || !q.Day.Equal(MintQuotaDay(at)) || q.Count < limit.Daily
+}
+
+// Next returns the quota after the user mints at the time
+func (q *MintQuota) Next(user string, at time.Time) *MintQuota {
+	day := MintQuotaDay(at)
+	if q == nil || !q.Day.Equal(day) {
+		return &MintQuota{User: user, Day: day, Count: 1, LastAt: at}
+	}
+	return &MintQuota{User: user, Day: day, Count: q.Count + 1, LastAt: at}
+}
+
+func MintQuotaDay(at time.Time) time.Time {
+	return at.UTC().Truncate(24 * time.Hour)
+}
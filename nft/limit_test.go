@@ -0,0 +1,76 @@
+package nft
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMintQuotaAllow(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	limit := &MintLimit{Interval: time.Minute, Daily: 3}
+	quota := &MintQuota{Day: MintQuotaDay(now), Count: 1, LastAt: now}
+	full := &MintQuota{Day: MintQuotaDay(now), Count: 3, LastAt: now}
+	late := time.Date(2024, 5, 1, 23, 59, 30, 0, time.UTC)
+	lateQuota := &MintQuota{Day: MintQuotaDay(late), Count: 3, LastAt: late}
+
+	tests := []struct {
+		name  string
+		quota *MintQuota
+		limit *MintLimit
+		at    time.Time
+		allow bool
+	}{
+		{"nil quota", nil, limit, now, true},
+		{"nil limit", full, nil, now, true},
+		{"zero limit", full, &MintLimit{}, now, true},
+		{"within interval", quota, limit, now.Add(30 * time.Second), false},
+		{"after interval", quota, limit, now.Add(time.Minute), true},
+		{"daily cap", full, limit, now.Add(time.Hour), false},
+		{"daily cap without interval", full, &MintLimit{Daily: 3}, now, false},
+		{"below daily cap", quota, &MintLimit{Daily: 3}, now, true},
+		{"unlimited daily", full, &MintLimit{Interval: time.Minute}, now.Add(time.Hour), true},
+		{"next day", full, limit, now.Add(24 * time.Hour), true},
+		{"day rollover within interval", lateQuota, limit, late.Add(45 * time.Second), false},
+		{"day rollover after interval", lateQuota, limit, late.Add(time.Minute), true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			allow := tc.quota.Allow(tc.limit, tc.at)
+			if allow != tc.allow {
+				t.Fatalf("Allow %v, want %v", allow, tc.allow)
+			}
+		})
+	}
+}
+
+func TestMintQuotaNext(t *testing.T) {
+	user := "4b188942-9fb0-4b99-b4be-e741a06d1ebf"
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	quota := &MintQuota{User: user, Day: MintQuotaDay(now), Count: 2, LastAt: now}
+
+	tests := []struct {
+		name  string
+		quota *MintQuota
+		at    time.Time
+		count int
+	}{
+		{"nil quota", nil, now, 1},
+		{"same day", quota, now.Add(time.Hour), 3},
+		{"next day", quota, now.Add(12 * time.Hour), 1},
+		{"local time same day", quota, now.Add(time.Hour).In(time.FixedZone("UTC+14", 14*3600)), 3},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			next := tc.quota.Next(user, tc.at)
+			if next.Count != tc.count {
+				t.Fatalf("count %d, want %d", next.Count, tc.count)
+			}
+			if !next.Day.Equal(MintQuotaDay(tc.at)) {
+				t.Fatalf("day %v, want %v", next.Day, MintQuotaDay(tc.at))
+			}
+			if !next.LastAt.Equal(tc.at) || next.User != user {
+				t.Fatalf("quota %v, want %s %v", next, user, tc.at)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package nft
+
+import (
+	"bytes"
+	"log/slog"
+	"net/url"
+	"unicode/utf8"
+
+	"github.com/MixinNetwork/trusted-group/mtg"
+	"github.com/gofrs/uuid"
+)
+
+const (
+	MetadataNameMaximumSize        = 64
+	MetadataSymbolMaximumSize      = 12
+	MetadataDescriptionMaximumSize = 128
+	MetadataURLMaximumSize         = 128
+)
+
+// MetadataOperation is sent by the collection creator to set the metadata
+type MetadataOperation struct {
+	Collection  uuid.UUID `msgpack:"C"`
+	Name        string    `msgpack:"N"`
+	Symbol      string    `msgpack:"S"`
+	Description string    `msgpack:"D"`
+	Icon        []byte    `msgpack:"I"`
+	URL         string    `msgpack:"U"`
+}
+
+func (mw *MintWorker) processMetadata(out *mtg.Output, mo *MetadataOperation) {
+	if !validMetadataString(mo.Name, 1, MetadataNameMaximumSize) ||
+		!validMetadataString(mo.Description, 0, MetadataDescriptionMaximumSize) {
+		return
+	}
+	if !ValidCollectionSymbol(mo.Symbol) {
+		return
+	}
+	if len(mo.Icon) != 0 && len(mo.Icon) != 32 {
+		return
+	}
+	if mo.URL != "" {
+		u, err := url.Parse(mo.URL)
+		if err != nil || u.Scheme != "https" || u.Host == "" || len(mo.URL) > MetadataURLMaximumSize {
+			return
+		}
+	}
+
+	og, events := mw.readCreatorCollection(out, mo.Collection)
+	if og == nil {
+		return
+	}
+	other, err := mw.store.ReadCollectionBySymbol(mo.Symbol)
+	if err != nil {
+		panic(err)
+	} else if other != nil && !bytes.Equal(other.Key, og.Key) {
+		slog.Info("MintWorker.metadata", "utxo", out.UTXOID, "collection", mo.Collection.String(), "symbol", mo.Symbol, "error", "symbol taken")
+		return
+	}
+
+	og.Name = mo.Name
+	og.Symbol = mo.Symbol
+	og.Description = mo.Description
+	og.Icon = mo.Icon
+	og.URL = mo.URL
+	err = mw.store.WriteMintCollection(og, events)
+	if err != nil {
+		panic(err)
+	}
+	slog.Info("MintWorker.metadata", "utxo", out.UTXOID, "collection", mo.Collection.String(), "symbol", og.Symbol, "sender", out.Sender)
+}
+
+// ValidCollectionSymbol allows at most 12 uppercase letters and digits
+func ValidCollectionSymbol(symbol string) bool {
+	if len(symbol) == 0 || len(symbol) > MetadataSymbolMaximumSize {
+		return false
+	}
+	for _, c := range symbol {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func validMetadataString(s string, min, max int) bool {
+	return len(s) >= min && len(s) <= max && utf8.ValidString(s)
+}
@@ -0,0 +1,29 @@
+package nft
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	RejectReasonAmount     = "amount"
+	RejectReasonSender     = "sender"
+	RejectReasonExists     = "exists"
+	RejectReasonCreator    = "creator"
+	RejectReasonSequential = "sequential"
+	RejectReasonExtra      = "extra"
+	RejectReasonLimit      = "limit"
+)
+
+var (
+	mintsAccepted = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "nfo",
+		Name:      "mints_accepted_total",
+		Help:      "Number of mint requests accepted by the group.",
+	})
+	mintsRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "nfo",
+		Name:      "mints_rejected_total",
+		Help:      "Number of mint requests rejected by the group, by reason.",
+	}, []string{"reason"})
+)
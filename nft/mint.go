@@ -3,11 +3,14 @@ package nft
 import (
 	"bytes"
 	"context"
+	"crypto/md5"
 	"encoding/base64"
 	"encoding/hex"
+	"log/slog"
 
-	"github.com/MixinNetwork/mixin/logger"
+	"github.com/MixinNetwork/mixin/crypto"
 	"github.com/MixinNetwork/trusted-group/mtg"
+	"github.com/fox-one/mixin-sdk-go"
 	"github.com/gofrs/uuid"
 	"github.com/shopspring/decimal"
 )
@@ -30,29 +33,41 @@ func NewMintWorker(grp *mtg.Group, store Store) *MintWorker {
 }
 
 func (mw *MintWorker) ProcessOutput(ctx context.Context, out *mtg.Output) {
-	logger.Verbosef("MintWorker.ProcessOutput(%v)\n", *out)
-	min, err := decimal.NewFromString(MintMinimumCost)
+	slog.Debug("MintWorker.ProcessOutput", "utxo", out.UTXOID, "asset", out.AssetID, "sender", out.Sender, "amount", out.Amount.String())
+	extra, err := base64.RawURLEncoding.DecodeString(out.Memo)
 	if err != nil {
 		return
 	}
+	if op, err := DecodeOperation(extra); err == nil {
+		mw.processOperation(ctx, out, op)
+		return
+	}
 	if out.AssetID != MintAssetId {
 		return
 	}
-	if out.Amount.Cmp(min) < 0 {
+	nfm, err := mtg.DecodeNFOMemo(extra)
+	if err != nil {
 		return
 	}
-	if uuid.FromStringOrNil(out.Sender).String() == uuid.Nil.String() {
+	if bytes.Compare(nfm.Encode(), extra) != 0 {
 		return
 	}
-	extra, err := base64.RawURLEncoding.DecodeString(out.Memo)
+
+	min, err := decimal.NewFromString(MintMinimumCost)
 	if err != nil {
 		return
 	}
-	nfm, err := mtg.DecodeNFOMemo(extra)
-	if err != nil {
+	if out.Amount.Cmp(min) < 0 {
+		mw.reject(out, nfm, RejectReasonAmount)
 		return
 	}
-	if bytes.Compare(nfm.Encode(), extra) != 0 {
+	if uuid.FromStringOrNil(out.Sender).String() == uuid.Nil.String() {
+		mw.reject(out, nfm, RejectReasonSender)
+		return
+	}
+	me, err := DecodeMintExtra(nfm.Extra)
+	if err != nil {
+		mw.reject(out, nfm, RejectReasonExtra)
 		return
 	}
 
@@ -61,26 +76,186 @@ func (mw *MintWorker) ProcessOutput(ctx context.Context, out *mtg.Output) {
 	if err != nil {
 		panic(err)
 	} else if old != nil {
+		mw.reject(out, nfm, RejectReasonExists)
 		return
 	}
 	og, err := mw.store.ReadMintCollection(ck)
 	if err != nil {
 		panic(err)
 	}
-	if og != nil && og.Creator != out.Sender && bytes.Compare(ck, mtg.NMDefaultCollectionKey) != 0 {
+	if og != nil && !og.CanMint(out.Sender) {
+		mw.reject(out, nfm, RejectReasonCreator)
 		return
 	}
-	err = mw.store.WriteMintToken(ck, nfm.Token, out.Sender)
+	if og != nil && og.Sequential {
+		mw.reject(out, nfm, RejectReasonSequential)
+		return
+	}
+	var approval *Event
+	if og != nil && og.IsMultisig() {
+		approval = mw.approve(out, og)
+		if approval == nil {
+			return
+		}
+	}
+	var quota *MintQuota
+	isDefault := bytes.Compare(ck, mtg.NMDefaultCollectionKey) == 0
+	if isDefault {
+		quota, err = mw.store.ReadMintQuota(out.Sender)
+		if err != nil {
+			panic(err)
+		}
+		limit, err := mw.store.ReadMintLimit(out.CreatedAt)
+		if err != nil {
+			panic(err)
+		}
+		if !quota.Allow(limit, out.CreatedAt) {
+			mw.reject(out, nfm, RejectReasonLimit)
+			mw.refund(ctx, out, RejectReasonLimit)
+			return
+		}
+	}
+	receivers, threshold := []string{out.Sender}, 1
+	if og != nil {
+		receivers, threshold = og.Owners(out.Sender)
+	}
+	if len(me.Receivers) > 0 {
+		receivers, threshold = me.Receivers, me.Threshold
+	}
+	if len(nfm.Extra) > len(me.Hash) {
+		nfm.Extra = me.Hash
+		extra = nfm.Encode()
+	}
+	traceId := MintTraceId(extra)
+	events := []*Event{{
+		Id:         mixin.UniqueConversationID(out.UTXOID, EventMint),
+		Kind:       EventMint,
+		Collection: nfm.Collection.String(),
+		Token:      hex.EncodeToString(nfm.Token),
+		TokenId:    BuildTokenId(nfm.Collection, nfm.Token),
+		User:       out.Sender,
+		AssetId:    out.AssetID,
+		Amount:     out.Amount.String(),
+		TraceId:    traceId,
+		CreatedAt:  out.CreatedAt,
+	}}
+	if og == nil {
+		events = append(events, buildCollectionEvent(out, nfm.Collection))
+	}
+	if approval != nil {
+		events = append(events, approval)
+	}
+	if isDefault {
+		quota = quota.Next(out.Sender, out.CreatedAt)
+		err = mw.store.WriteDefaultMintToken(nfm.Token, out.Sender, out.CreatedAt, me.Traits, quota, events)
+	} else {
+		err = mw.store.WriteMintToken(ck, nfm.Token, out.Sender, out.CreatedAt, me.Traits, events)
+	}
 	if err != nil {
 		panic(err)
 	}
-	err = mw.grp.BuildCollectibleMintTransaction(ctx, []string{out.Sender}, 1, extra)
-	logger.Verbosef("MintWorker.BuildCollectibleMintTransaction(%s, %s)\n", out.Sender, hex.EncodeToString(extra))
+	err = mw.grp.BuildCollectibleMintTransaction(ctx, receivers, threshold, extra)
 	if err != nil {
 		panic(err)
 	}
+	mintsAccepted.Inc()
+	slog.Info("MintWorker.mint", "utxo", out.UTXOID, "trace", traceId,
+		"collection", nfm.Collection.String(), "token", hex.EncodeToString(nfm.Token), "sender", out.Sender, "receivers", receivers)
 }
 
 func (mw *MintWorker) ProcessCollectibleOutput(ctx context.Context, out *mtg.CollectibleOutput) {
-	logger.Verbosef("MintWorker.ProcessCollectibleOutput(%v)\n", *out)
+	slog.Debug("MintWorker.ProcessCollectibleOutput", "output", out.OutputId, "token", out.TokenId, "senders", out.Senders)
+	if out.State != mtg.OutputStateUnspent || len(out.Senders) == 0 {
+		return
+	}
+	token, err := mw.store.ReadTokenByTokenId(out.TokenId)
+	if err != nil {
+		panic(err)
+	}
+	ev := &Event{
+		Id:        mixin.UniqueConversationID(out.OutputId, EventReceive),
+		Kind:      EventReceive,
+		TokenId:   out.TokenId,
+		User:      out.Senders[0],
+		CreatedAt: out.CreatedAt,
+	}
+	if token != nil {
+		ev.Collection = uuid.FromBytesOrNil(token.Collection).String()
+		ev.Token = hex.EncodeToString(token.Key)
+	}
+	err = mw.store.WriteEvent(ev)
+	if err != nil {
+		panic(err)
+	}
+
+	op, err := DecodeCollectibleOperationMemo(out.Memo)
+	if err != nil || op.Purpose != OperationPurposeBurn {
+		return
+	}
+	var burn BurnOperation
+	err = op.Unmarshal(&burn)
+	if err != nil || BuildTokenId(burn.Collection, burn.Token) != out.TokenId {
+		return
+	}
+	ck := burn.Collection.Bytes()
+	old, err := mw.store.ReadMintToken(ck, burn.Token)
+	if err != nil {
+		panic(err)
+	} else if old == nil || old.Burned {
+		return
+	}
+	err = mw.store.WriteBurnToken(ck, burn.Token, &Event{
+		Id:         mixin.UniqueConversationID(out.OutputId, EventBurn),
+		Kind:       EventBurn,
+		Collection: burn.Collection.String(),
+		Token:      hex.EncodeToString(burn.Token),
+		TokenId:    out.TokenId,
+		User:       out.Senders[0],
+		CreatedAt:  out.CreatedAt,
+	})
+	if err != nil {
+		panic(err)
+	}
+	slog.Info("MintWorker.burn", "output", out.OutputId, "collection", burn.Collection.String(),
+		"token", hex.EncodeToString(burn.Token), "sender", out.Senders[0])
+}
+
+func (mw *MintWorker) reject(out *mtg.Output, nfm *mtg.NFOMemo, reason string) {
+	err := mw.store.WriteEvent(&Event{
+		Id:         mixin.UniqueConversationID(out.UTXOID, EventReject),
+		Kind:       EventReject,
+		Collection: nfm.Collection.String(),
+		Token:      hex.EncodeToString(nfm.Token),
+		User:       out.Sender,
+		AssetId:    out.AssetID,
+		Amount:     out.Amount.String(),
+		Hash:       out.TransactionHash.String(),
+		Reason:     reason,
+		CreatedAt:  out.CreatedAt,
+	})
+	if err != nil {
+		panic(err)
+	}
+	mintsRejected.WithLabelValues(reason).Inc()
+	slog.Info("MintWorker.reject", "utxo", out.UTXOID, "reason", reason,
+		"collection", nfm.Collection.String(), "token", hex.EncodeToString(nfm.Token), "sender", out.Sender)
+}
+
+// BuildTokenId returns the Mixin collectible token id of the NFO,
+// which is the uuid hash of chain || class || collection || token
+func BuildTokenId(collection uuid.UUID, token []byte) string {
+	b := append(mtg.NMDefaultChain.Bytes(), mtg.NMDefaultClass...)
+	b = append(b, collection.Bytes()...)
+	b = append(b, token...)
+	sum := md5.Sum(b)
+	sum[6] = (sum[6] & 0x0f) | 0x30
+	sum[8] = (sum[8] & 0x3f) | 0x80
+	return uuid.FromBytesOrNil(sum[:]).String()
+}
+
+// MintTraceId is the trace id of the collectible transaction built by
+// BuildCollectibleMintTransaction for the nfo
+func MintTraceId(nfo []byte) string {
+	nid := crypto.NewHash(nfo).String()
+	return mixin.UniqueConversationID(nid, nid)
 }
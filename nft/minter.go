@@ -0,0 +1,63 @@
+package nft
+
+import (
+	"log/slog"
+
+	"github.com/MixinNetwork/trusted-group/mtg"
+	"github.com/gofrs/uuid"
+)
+
+const CollectionMaximumMinters = 32
+
+// MinterOperation grants or revokes the minting right of the user
+type MinterOperation struct {
+	Collection uuid.UUID `msgpack:"C"`
+	User       uuid.UUID `msgpack:"U"`
+}
+
+func (mw *MintWorker) processMinter(out *mtg.Output, mo *MinterOperation, granted bool) {
+	if mo.User == uuid.Nil || mo.User.String() == out.Sender {
+		return
+	}
+	if granted {
+		og, err := mw.store.ReadMintCollection(mo.Collection.Bytes())
+		if err != nil {
+			panic(err)
+		}
+		if og != nil && og.IsMultisig() {
+			return
+		}
+	}
+	og, events := mw.readCreatorCollection(out, mo.Collection)
+	if og == nil {
+		return
+	}
+
+	user := mo.User.String()
+	minters := make([]string, 0, len(og.Minters)+1)
+	for _, m := range og.Minters {
+		if m != user {
+			minters = append(minters, m)
+		}
+	}
+	if granted {
+		minters = append(minters, user)
+	}
+	if len(minters) == len(og.Minters) || len(minters) > CollectionMaximumMinters {
+		return
+	}
+	og.Minters = minters
+
+	err := mw.store.WriteMinterChange(og, &MinterChange{
+		Collection: og.Key,
+		User:       user,
+		Granted:    granted,
+		Sender:     out.Sender,
+		OutputId:   out.UTXOID,
+		CreatedAt:  out.CreatedAt,
+	}, events)
+	if err != nil {
+		panic(err)
+	}
+	slog.Info("MintWorker.minter", "utxo", out.UTXOID, "collection", mo.Collection.String(), "user", user, "granted", granted, "sender", out.Sender)
+}
@@ -0,0 +1,119 @@
+package nft
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/MixinNetwork/trusted-group/mtg"
+	"github.com/gofrs/uuid"
+)
+
+// Operations other than mint are sent to the MTG with the memo
+// base64(prefix || version || purpose || msgpack(body)), and the body
+// uses single letter field names to keep the memo short.
+
+const (
+	OperationPrefix  = "NFA"
+	OperationVersion = 0x00
+
+	OperationPurposeBurn     = 1
+	OperationPurposeRoyalty  = 2
+	OperationPurposeSale     = 3
+	OperationPurposePurchase = 4
+	OperationPurposeOption   = 5
+	OperationPurposeMint     = 6
+	OperationPurposeMetadata = 7
+	OperationPurposeGrant    = 8
+	OperationPurposeRevoke   = 9
+	OperationPurposePolicy   = 10
+	OperationPurposeLimit    = 11
+
+	OperationPurposeList        = 16
+	OperationPurposeBuy         = 17
+	OperationPurposeCancelList  = 18
+	OperationPurposeAuction     = 19
+	OperationPurposeBid         = 20
+	OperationPurposeOffer       = 21
+	OperationPurposeAccept      = 22
+	OperationPurposeCancelOffer = 23
+	OperationPurposeSwap        = 24
+)
+
+type Operation struct {
+	Purpose byte
+	Body    []byte
+}
+
+// BurnOperation is sent along with the collectible to burn, the MTG keeps
+// the collectible forever
+type BurnOperation struct {
+	Collection uuid.UUID `msgpack:"C"`
+	Token      []byte    `msgpack:"T"`
+}
+
+// RoyaltyOperation is sent by the collection creator to set the royalty,
+// and a zero rate removes the royalty
+type RoyaltyOperation struct {
+	Collection uuid.UUID                   `msgpack:"C"`
+	Rate       int                         `msgpack:"R"`
+	Recipients []RoyaltyRecipientOperation `msgpack:"P"`
+}
+
+type RoyaltyRecipientOperation struct {
+	User  uuid.UUID `msgpack:"U"`
+	Share int       `msgpack:"S"`
+}
+
+func BuildOperation(purpose byte, body any) []byte {
+	b := []byte(OperationPrefix)
+	b = append(b, OperationVersion, purpose)
+	return append(b, mtg.MsgpackMarshalPanic(body)...)
+}
+
+func BuildOperationMemo(purpose byte, body any) string {
+	return base64.RawURLEncoding.EncodeToString(BuildOperation(purpose, body))
+}
+
+func DecodeOperation(b []byte) (*Operation, error) {
+	if len(b) < 5 {
+		return nil, fmt.Errorf("operation length %d", len(b))
+	}
+	if string(b[:3]) != OperationPrefix {
+		return nil, fmt.Errorf("operation prefix %v", b[:3])
+	}
+	if b[3] != OperationVersion {
+		return nil, fmt.Errorf("operation version %v", b[3])
+	}
+	return &Operation{Purpose: b[4], Body: b[5:]}, nil
+}
+
+// DecodeOperationMemo decodes the operation from a multisig output memo
+func DecodeOperationMemo(memo string) (*Operation, error) {
+	b, err := base64.RawURLEncoding.DecodeString(memo)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeOperation(b)
+}
+
+// DecodeCollectibleOperationMemo decodes the operation from a collectible
+// output memo, which is the hex of the NFO extra with the operation memo
+func DecodeCollectibleOperationMemo(memo string) (*Operation, error) {
+	extra, err := hex.DecodeString(memo)
+	if err != nil {
+		return nil, err
+	}
+	nfm, err := mtg.DecodeNFOMemo(extra)
+	if err != nil {
+		return nil, err
+	}
+	if nfm.WillMint() {
+		return nil, fmt.Errorf("operation with mint NFO %s", memo)
+	}
+	return DecodeOperationMemo(string(nfm.Extra))
+}
+
+func (op *Operation) Unmarshal(body any) error {
+	return mtg.MsgpackUnmarshal(op.Body, body)
+}
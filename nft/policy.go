@@ -0,0 +1,81 @@
+package nft
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/MixinNetwork/trusted-group/mtg"
+	"github.com/fox-one/mixin-sdk-go"
+	"github.com/gofrs/uuid"
+)
+
+const (
+	CollectionMaximumMembers = 8
+	CollectionApprovalWindow = 24 * time.Hour
+)
+
+// PolicyOperation makes the collection controlled by the members
+type PolicyOperation struct {
+	Collection uuid.UUID   `msgpack:"C"`
+	Members    []uuid.UUID `msgpack:"M"`
+	Threshold  int         `msgpack:"T"`
+}
+
+func (mw *MintWorker) processPolicy(out *mtg.Output, po *PolicyOperation) {
+	if len(po.Members) == 0 || len(po.Members) > CollectionMaximumMembers {
+		return
+	}
+	if po.Threshold < 1 || po.Threshold > len(po.Members) {
+		return
+	}
+	var members []string
+	filter := make(map[uuid.UUID]bool)
+	for _, m := range po.Members {
+		if m == uuid.Nil || filter[m] {
+			return
+		}
+		filter[m] = true
+		members = append(members, m.String())
+	}
+
+	og, events := mw.readCreatorCollection(out, po.Collection)
+	if og == nil {
+		return
+	}
+	og.Members = members
+	og.Threshold = po.Threshold
+	err := mw.store.WriteMintCollection(og, events)
+	if err != nil {
+		panic(err)
+	}
+	slog.Info("MintWorker.policy", "utxo", out.UTXOID, "collection", po.Collection.String(),
+		"members", og.Members, "threshold", og.Threshold, "sender", out.Sender)
+}
+
+// approve returns the approval event once the threshold of the members sent
+// the same memo in the window
+func (mw *MintWorker) approve(out *mtg.Output, og *Collection) *Event {
+	if !og.IsMember(out.Sender) {
+		return nil
+	}
+	action := crypto.NewHash([]byte(out.Memo)).String()
+	since := out.CreatedAt.Add(-CollectionApprovalWindow)
+	count, err := mw.store.WriteApproval(og, action, out.Sender, out.CreatedAt, since)
+	if err != nil {
+		panic(err)
+	}
+	if count < og.Threshold {
+		slog.Info("MintWorker.approve", "utxo", out.UTXOID, "collection", uuid.FromBytesOrNil(og.Key).String(),
+			"action", action, "approvals", count, "threshold", og.Threshold, "sender", out.Sender)
+		return nil
+	}
+	return &Event{
+		Id:         mixin.UniqueConversationID(out.UTXOID, EventApproval),
+		Kind:       EventApproval,
+		Collection: uuid.FromBytesOrNil(og.Key).String(),
+		User:       out.Sender,
+		Action:     action,
+		CreatedAt:  out.CreatedAt,
+	}
+}
@@ -0,0 +1,23 @@
+package nft
+
+import (
+	"time"
+)
+
+const (
+	ProvenanceMint    = "mint"
+	ProvenanceReceive = "receive"
+	ProvenanceSend    = "send"
+	ProvenanceBurn    = "burn"
+)
+
+// Provenance is a state change of the token in the chain of custody, the
+// users are the receivers of a mint or send, or the senders of a receive
+type Provenance struct {
+	TokenId   string
+	Kind      string
+	Hash      string
+	Users     []string
+	Threshold int
+	CreatedAt time.Time
+}
@@ -0,0 +1,245 @@
+package nft
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"time"
+
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/MixinNetwork/trusted-group/mtg"
+	"github.com/fox-one/mixin-sdk-go"
+	"github.com/gofrs/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	MemoRefund   = "REFUND"
+	MemoProceeds = "PROCEEDS"
+)
+
+// SaleOperation is sent by the collection creator to configure the public
+// mint, the start and end are in unix seconds, the limit of tokens for each
+// buyer is unlimited if 0, the root is the optional allowlist root, and the
+// hash is the content hash of the tokens minted by the sale
+type SaleOperation struct {
+	Collection uuid.UUID `msgpack:"C"`
+	Asset      uuid.UUID `msgpack:"A"`
+	Price      string    `msgpack:"P"`
+	Start      int64     `msgpack:"S"`
+	End        int64     `msgpack:"E"`
+	Limit      int       `msgpack:"L"`
+	Supply     int       `msgpack:"N"`
+	Root       []byte    `msgpack:"R"`
+	Hash       []byte    `msgpack:"H"`
+}
+
+// PurchaseOperation is sent along with the exact price to mint the next
+// token of the collection, and the proof is required by the allowlist
+type PurchaseOperation struct {
+	Collection uuid.UUID `msgpack:"C"`
+	Proof      [][]byte  `msgpack:"P"`
+}
+
+func (mw *MintWorker) processSale(out *mtg.Output, so *SaleOperation) {
+	price, err := decimal.NewFromString(so.Price)
+	if err != nil || !price.IsPositive() || !price.Equal(price.Truncate(8)) {
+		return
+	}
+	if so.Asset == uuid.Nil || so.End <= so.Start || so.Supply <= 0 || so.Limit < 0 {
+		return
+	}
+	if len(so.Root) != 0 && len(so.Root) != sha256.Size {
+		return
+	}
+	if len(so.Hash) != 0 && len(so.Hash) != len(crypto.Hash{}) {
+		return
+	}
+
+	og, events := mw.readCreatorCollection(out, so.Collection)
+	if og == nil {
+		return
+	}
+	s := &Sale{
+		Collection: og.Key,
+		AssetId:    so.Asset.String(),
+		Price:      price.String(),
+		StartAt:    time.Unix(so.Start, 0),
+		EndAt:      time.Unix(so.End, 0),
+		Limit:      so.Limit,
+		Supply:     so.Supply,
+		Root:       so.Root,
+		Hash:       so.Hash,
+	}
+	old, err := mw.store.ReadMintSale(og.Key)
+	if err != nil {
+		panic(err)
+	} else if old != nil {
+		s.Minted = old.Minted
+	}
+	// the hash is fixed once any token is minted, so that a replayed
+	// purchase builds the same NFO
+	if old != nil && old.Minted > 0 {
+		s.Hash = old.Hash
+	}
+	err = mw.store.WriteMintSale(og, s, events)
+	if err != nil {
+		panic(err)
+	}
+	slog.Info("MintWorker.sale", "utxo", out.UTXOID, "collection", so.Collection.String(),
+		"asset", s.AssetId, "price", s.Price, "supply", s.Supply, "sender", out.Sender)
+}
+
+// processPurchase mints the next token to the buyer and forwards the payment
+// to the creator, the purchase is recorded by the output so that a replay
+// builds the same transactions
+func (mw *MintWorker) processPurchase(ctx context.Context, out *mtg.Output, po *PurchaseOperation) {
+	ck := po.Collection.Bytes()
+	id, err := mw.store.ReadAssignedToken(out.UTXOID)
+	if err != nil {
+		panic(err)
+	}
+	if id == nil {
+		id = mw.purchase(ctx, out, po)
+		if id == nil {
+			return
+		}
+	}
+	og, err := mw.store.ReadMintCollection(ck)
+	if err != nil {
+		panic(err)
+	}
+	s, err := mw.store.ReadMintSale(ck)
+	if err != nil {
+		panic(err)
+	}
+
+	nfo := mtg.BuildMintNFO(po.Collection.String(), id, s.ContentHash())
+	err = mw.grp.BuildCollectibleMintTransaction(ctx, []string{out.Sender}, 1, nfo)
+	if err != nil {
+		panic(err)
+	}
+	traceId := mixin.UniqueConversationID(out.UTXOID, "proceeds")
+	receivers, threshold := og.Owners(og.Creator)
+	err = mw.grp.BuildTransaction(ctx, out.AssetID, receivers, threshold, out.Amount.String(), MemoProceeds, traceId, "")
+	if err != nil {
+		panic(err)
+	}
+	mintsAccepted.Inc()
+	slog.Info("MintWorker.purchase", "utxo", out.UTXOID, "trace", MintTraceId(nfo),
+		"collection", po.Collection.String(), "token", hex.EncodeToString(id), "sender", out.Sender)
+}
+
+// purchase validates the payment against the sale, and writes the next
+// token id for the buyer, or refunds the payment and returns nil
+func (mw *MintWorker) purchase(ctx context.Context, out *mtg.Output, po *PurchaseOperation) []byte {
+	ck := po.Collection.Bytes()
+	s, err := mw.store.ReadMintSale(ck)
+	if err != nil {
+		panic(err)
+	}
+	if s == nil || out.CreatedAt.Before(s.StartAt) || !out.CreatedAt.Before(s.EndAt) {
+		mw.refund(ctx, out, "sale")
+		return nil
+	}
+	price, _ := decimal.NewFromString(s.Price)
+	if out.AssetID != s.AssetId || !out.Amount.Equal(price) {
+		mw.refund(ctx, out, "price")
+		return nil
+	}
+	if len(s.Root) > 0 && !VerifyAllowlist(s.Root, uuid.FromStringOrNil(out.Sender), po.Proof) {
+		mw.refund(ctx, out, "allowlist")
+		return nil
+	}
+	if s.Minted >= s.Supply {
+		mw.refund(ctx, out, "sold")
+		return nil
+	}
+	count, err := mw.store.ReadMintSaleCount(ck, out.Sender)
+	if err != nil {
+		panic(err)
+	}
+	if s.Limit > 0 && count >= s.Limit {
+		mw.refund(ctx, out, "limit")
+		return nil
+	}
+
+	og, err := mw.store.ReadMintCollection(ck)
+	if err != nil {
+		panic(err)
+	}
+	sequence, id := mw.nextSequence(og)
+	nfo := mtg.BuildMintNFO(po.Collection.String(), id, s.ContentHash())
+	// the payment is the sale proceeds instead of a mint fee
+	events := []*Event{{
+		Id:         mixin.UniqueConversationID(out.UTXOID, EventMint),
+		Kind:       EventMint,
+		Collection: po.Collection.String(),
+		Token:      hex.EncodeToString(id),
+		TokenId:    BuildTokenId(po.Collection, id),
+		User:       out.Sender,
+		TraceId:    MintTraceId(nfo),
+		CreatedAt:  out.CreatedAt,
+	}, {
+		Id:         mixin.UniqueConversationID(out.UTXOID, EventPurchase),
+		Kind:       EventPurchase,
+		Collection: po.Collection.String(),
+		Token:      hex.EncodeToString(id),
+		TokenId:    BuildTokenId(po.Collection, id),
+		User:       out.Sender,
+		AssetId:    out.AssetID,
+		Amount:     out.Amount.String(),
+		TraceId:    mixin.UniqueConversationID(out.UTXOID, "proceeds"),
+		CreatedAt:  out.CreatedAt,
+	}}
+	err = mw.store.WriteSaleToken(s, sequence, id, out.Sender, out.UTXOID, out.CreatedAt, events)
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+func (mw *MintWorker) refund(ctx context.Context, out *mtg.Output, reason string) {
+	traceId := mixin.UniqueConversationID(out.UTXOID, "refund")
+	err := mw.grp.BuildTransaction(ctx, out.AssetID, []string{out.Sender}, 1, out.Amount.String(), MemoRefund, traceId, "")
+	if err != nil {
+		panic(err)
+	}
+	err = mw.store.WriteEvent(&Event{
+		Id:        mixin.UniqueConversationID(out.UTXOID, EventRefund),
+		Kind:      EventRefund,
+		User:      out.Sender,
+		AssetId:   out.AssetID,
+		Amount:    out.Amount.String(),
+		TraceId:   traceId,
+		Reason:    reason,
+		CreatedAt: out.CreatedAt,
+	})
+	if err != nil {
+		panic(err)
+	}
+	slog.Info("MintWorker.refund", "utxo", out.UTXOID, "trace", traceId, "sender", out.Sender, "reason", reason)
+}
+
+// VerifyAllowlist verifies the proof of the user against the merkle root,
+// the leaf is sha256 of the user uuid bytes, and each parent is sha256 of
+// its two children in ascending order
+func VerifyAllowlist(root []byte, user uuid.UUID, proof [][]byte) bool {
+	if user == uuid.Nil {
+		return false
+	}
+	node := sha256.Sum256(user.Bytes())
+	for _, p := range proof {
+		if len(p) != sha256.Size {
+			return false
+		}
+		a, b := node[:], p
+		if bytes.Compare(a, b) > 0 {
+			a, b = b, a
+		}
+		node = sha256.Sum256(append(append([]byte{}, a...), b...))
+	}
+	return bytes.Equal(node[:], root)
+}
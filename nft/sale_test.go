@@ -0,0 +1,67 @@
+package nft
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/gofrs/uuid"
+)
+
+func TestVerifyAllowlist(t *testing.T) {
+	users := []uuid.UUID{
+		uuid.FromStringOrNil("4b188942-9fb0-4b99-b4be-e741a06d1ebf"),
+		uuid.FromStringOrNil("dd655520-c919-4349-822f-af92fabdbdf4"),
+		uuid.FromStringOrNil("c6d0c728-2624-429b-8e0d-d9d19b6592fa"),
+		uuid.FromStringOrNil("a0a9b2c5-2b7c-4b7e-9a3c-2e0b6c6d1f01"),
+	}
+	parent := func(a, b []byte) []byte {
+		if bytes.Compare(a, b) > 0 {
+			a, b = b, a
+		}
+		h := sha256.Sum256(append(append([]byte{}, a...), b...))
+		return h[:]
+	}
+	var leaves [][]byte
+	for _, u := range users {
+		h := sha256.Sum256(u.Bytes())
+		leaves = append(leaves, h[:])
+	}
+	left, right := parent(leaves[0], leaves[1]), parent(leaves[2], leaves[3])
+	root := parent(left, right)
+	single := leaves[0]
+	outsider := uuid.FromStringOrNil("0b5b8a86-6c38-4e2e-9d2c-7f4b8d7a3e11")
+
+	tests := []struct {
+		name  string
+		root  []byte
+		user  uuid.UUID
+		proof [][]byte
+		valid bool
+	}{
+		{"first leaf", root, users[0], [][]byte{leaves[1], right}, true},
+		{"second leaf", root, users[1], [][]byte{leaves[0], right}, true},
+		{"third leaf", root, users[2], [][]byte{leaves[3], left}, true},
+		{"fourth leaf", root, users[3], [][]byte{leaves[2], left}, true},
+		{"single user empty proof", single, users[0], nil, true},
+		{"empty proof", root, users[0], nil, false},
+		{"empty proof other user", single, users[1], nil, false},
+		{"wrong sibling", root, users[0], [][]byte{leaves[2], right}, false},
+		{"wrong user", root, users[1], [][]byte{leaves[1], right}, false},
+		{"outsider", root, outsider, [][]byte{leaves[1], right}, false},
+		{"nil user", root, uuid.Nil, [][]byte{leaves[1], right}, false},
+		{"short proof", root, users[0], [][]byte{leaves[1]}, false},
+		{"long proof", root, users[0], [][]byte{leaves[1], right, left}, false},
+		{"short node", root, users[0], [][]byte{leaves[1][:31], right}, false},
+		{"long node", root, users[0], [][]byte{append(leaves[1], 0), right}, false},
+		{"empty root", nil, users[0], [][]byte{leaves[1], right}, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			valid := VerifyAllowlist(tc.root, tc.user, tc.proof)
+			if valid != tc.valid {
+				t.Fatalf("VerifyAllowlist %v, want %v", valid, tc.valid)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package nft
+
+import (
+	"context"
+	"encoding/hex"
+	"log/slog"
+	"math/big"
+
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/MixinNetwork/trusted-group/mtg"
+	"github.com/fox-one/mixin-sdk-go"
+	"github.com/gofrs/uuid"
+)
+
+// OptionOperation is sent by the collection creator to change the options,
+// a sequential collection only accepts the mint operation, and the token id
+// is assigned by the MTG as the next integer not minted
+type OptionOperation struct {
+	Collection uuid.UUID `msgpack:"C"`
+	Sequential bool      `msgpack:"Q"`
+}
+
+// MintOperation is sent by the collection creator to mint the next token of
+// a sequential collection, the hash is optional
+type MintOperation struct {
+	Collection uuid.UUID `msgpack:"C"`
+	Hash       []byte    `msgpack:"H"`
+}
+
+func (mw *MintWorker) processOption(out *mtg.Output, oo *OptionOperation) {
+	og, events := mw.readCreatorCollection(out, oo.Collection)
+	if og == nil {
+		return
+	}
+	og.Sequential = oo.Sequential
+	err := mw.store.WriteMintCollection(og, events)
+	if err != nil {
+		panic(err)
+	}
+	slog.Info("MintWorker.option", "utxo", out.UTXOID, "collection", oo.Collection.String(), "sequential", og.Sequential, "sender", out.Sender)
+}
+
+// processSequenceMint assigns the next token id of the sequential collection,
+// the id is recorded by the output so that a replay builds the same mint
+func (mw *MintWorker) processSequenceMint(ctx context.Context, out *mtg.Output, mo *MintOperation) {
+	var hash crypto.Hash
+	if len(mo.Hash) != 0 && len(mo.Hash) != len(hash) {
+		return
+	}
+	copy(hash[:], mo.Hash)
+
+	ck := mo.Collection.Bytes()
+	nfm := &mtg.NFOMemo{Collection: mo.Collection}
+	id, err := mw.store.ReadAssignedToken(out.UTXOID)
+	if err != nil {
+		panic(err)
+	}
+	if id == nil {
+		og, err := mw.store.ReadMintCollection(ck)
+		if err != nil {
+			panic(err)
+		}
+		if og == nil || !og.Sequential {
+			mw.reject(out, nfm, RejectReasonSequential)
+			return
+		}
+		if !og.CanMint(out.Sender) {
+			mw.reject(out, nfm, RejectReasonCreator)
+			return
+		}
+		var approval *Event
+		if og.IsMultisig() {
+			approval = mw.approve(out, og)
+			if approval == nil {
+				return
+			}
+		}
+
+		var sequence uint64
+		sequence, id = mw.nextSequence(og)
+		nfo := mtg.BuildMintNFO(mo.Collection.String(), id, hash)
+		events := []*Event{{
+			Id:         mixin.UniqueConversationID(out.UTXOID, EventMint),
+			Kind:       EventMint,
+			Collection: mo.Collection.String(),
+			Token:      hex.EncodeToString(id),
+			TokenId:    BuildTokenId(mo.Collection, id),
+			User:       out.Sender,
+			AssetId:    out.AssetID,
+			Amount:     out.Amount.String(),
+			TraceId:    MintTraceId(nfo),
+			CreatedAt:  out.CreatedAt,
+		}}
+		if approval != nil {
+			events = append(events, approval)
+		}
+		err = mw.store.WriteSequenceToken(ck, sequence, id, out.Sender, out.UTXOID, out.CreatedAt, events)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	og, err := mw.store.ReadMintCollection(ck)
+	if err != nil {
+		panic(err)
+	}
+	receivers, threshold := og.Owners(out.Sender)
+	nfo := mtg.BuildMintNFO(mo.Collection.String(), id, hash)
+	err = mw.grp.BuildCollectibleMintTransaction(ctx, receivers, threshold, nfo)
+	if err != nil {
+		panic(err)
+	}
+	mintsAccepted.Inc()
+	slog.Info("MintWorker.mint", "utxo", out.UTXOID, "trace", MintTraceId(nfo),
+		"collection", mo.Collection.String(), "token", hex.EncodeToString(id), "sender", out.Sender)
+}
+
+// nextSequence returns the next sequence of the collection, and its token
+// id as big-endian bytes, skipping the ids already minted
+func (mw *MintWorker) nextSequence(og *Collection) (uint64, []byte) {
+	sequence := og.Sequence
+	for {
+		sequence += 1
+		id := new(big.Int).SetUint64(sequence).Bytes()
+		old, err := mw.store.ReadMintToken(og.Key, id)
+		if err != nil {
+			panic(err)
+		} else if old == nil {
+			return sequence, id
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package store
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/MixinNetwork/nfo/nft"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/gofrs/uuid"
+)
+
+const (
+	prefixMintApproval = "COLLECTIBLES:MINT:APPROVAL:"
+)
+
+// WriteApproval records the approval and counts the recent member approvals
+func (bs *BadgerStore) WriteApproval(og *nft.Collection, action, user string, createdAt, since time.Time) (int, error) {
+	var count int
+	err := bs.db.Update(func(txn *badger.Txn) error {
+		prefix := buildApprovalPrefix(og.Key, action)
+		err := txn.Set(append(prefix, user...), tsToBytes(createdAt))
+		if err != nil {
+			return err
+		}
+
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(opts.Prefix); it.Valid(); it.Next() {
+			member := string(it.Item().Key()[len(prefix):])
+			if !og.IsMember(member) {
+				continue
+			}
+			val, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			if bytes.Compare(val, tsToBytes(since)) >= 0 {
+				count += 1
+			}
+		}
+		return nil
+	})
+	return count, err
+}
+
+// deleteApprovals clears the approvals consumed by the approved action
+func (bs *BadgerStore) deleteApprovals(txn *badger.Txn, events []*nft.Event) error {
+	for _, ev := range events {
+		if ev.Kind != nft.EventApproval {
+			continue
+		}
+		collection := uuid.FromStringOrNil(ev.Collection).Bytes()
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = buildApprovalPrefix(collection, ev.Action)
+		it := txn.NewIterator(opts)
+
+		var keys [][]byte
+		for it.Seek(opts.Prefix); it.Valid(); it.Next() {
+			keys = append(keys, it.Item().KeyCopy(nil))
+		}
+		it.Close()
+		for _, k := range keys {
+			err := txn.Delete(k)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func buildApprovalPrefix(collection []byte, action string) []byte {
+	prefix := append([]byte(prefixMintApproval), collection...)
+	return append(prefix, action...)
+}
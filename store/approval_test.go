@@ -0,0 +1,83 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MixinNetwork/nfo/nft"
+	"github.com/gofrs/uuid"
+)
+
+func TestWriteApproval(t *testing.T) {
+	bs, err := OpenBadger(context.Background(), t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bs.Close()
+
+	members := []string{
+		"4b188942-9fb0-4b99-b4be-e741a06d1ebf",
+		"dd655520-c919-4349-822f-af92fabdbdf4",
+		"c6d0c728-2624-429b-8e0d-d9d19b6592fa",
+	}
+	outsider := "0b5b8a86-6c38-4e2e-9d2c-7f4b8d7a3e11"
+	collection := uuid.FromStringOrNil("a0a9b2c5-2b7c-4b7e-9a3c-2e0b6c6d1f01")
+	og := &nft.Collection{Key: collection.Bytes(), Members: members, Threshold: 2}
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	since := now.Add(-nft.CollectionApprovalWindow)
+
+	tests := []struct {
+		name   string
+		action string
+		user   string
+		at     time.Time
+		count  int
+	}{
+		{"first member", "action-a", members[0], now, 1},
+		{"same member again", "action-a", members[0], now.Add(time.Second), 1},
+		{"outsider", "action-a", outsider, now.Add(time.Second), 1},
+		{"other action", "action-b", members[1], now.Add(time.Second), 1},
+		{"second member", "action-a", members[1], now.Add(time.Second), 2},
+		{"expired approval", "action-b", members[2], since.Add(-time.Second), 1},
+		{"third member", "action-a", members[2], now.Add(time.Second), 3},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			count, err := bs.WriteApproval(og, tc.action, tc.user, tc.at, since)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if count != tc.count {
+				t.Fatalf("approvals %d, want %d", count, tc.count)
+			}
+		})
+	}
+
+	ev := &nft.Event{
+		Id:         "3c6d3b1a-58f9-3d8e-8f3c-0c5c1d2e4f10",
+		Kind:       nft.EventApproval,
+		Collection: collection.String(),
+		User:       members[2],
+		Action:     "action-a",
+		CreatedAt:  now,
+	}
+	err = bs.WriteMintCollection(og, []*nft.Event{ev})
+	if err != nil {
+		t.Fatal(err)
+	}
+	count, err := bs.WriteApproval(og, "action-a", members[0], now.Add(time.Minute), since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatalf("approvals %d after clearing, want 1", count)
+	}
+	count, err = bs.WriteApproval(og, "action-b", members[0], now.Add(time.Minute), since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Fatalf("approvals %d of other action, want 2", count)
+	}
+}
@@ -0,0 +1,114 @@
+package store
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/MixinNetwork/nfo/market"
+	"github.com/MixinNetwork/nfo/nft"
+	"github.com/MixinNetwork/trusted-group/mtg"
+	"github.com/dgraph-io/badger/v4"
+)
+
+const (
+	prefixAuctionPayload = "MARKET:AUCTION:PAYLOAD:"
+	prefixAuctionExpiry  = "MARKET:AUCTION:EXPIRY:"
+)
+
+func (bs *BadgerStore) WriteAuction(a *market.Auction, events []*nft.Event) error {
+	return bs.db.Update(func(txn *badger.Txn) error {
+		old, err := bs.readAuction(txn, a.Id)
+		if err != nil {
+			return err
+		}
+		if old != nil && old.State == market.AuctionStateActive {
+			err = txn.Delete(buildAuctionExpiryKey(old))
+			if err != nil {
+				return err
+			}
+		}
+
+		key := []byte(prefixAuctionPayload + a.Id)
+		err = txn.Set(key, mtg.MsgpackMarshalPanic(a))
+		if err != nil {
+			return err
+		}
+		if a.State == market.AuctionStateActive {
+			err = txn.Set(buildAuctionExpiryKey(a), []byte{1})
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, ev := range events {
+			err = bs.writeEvent(txn, ev)
+			if err != nil {
+				return err
+			}
+			err = bs.writeCollectionStats(txn, ev)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (bs *BadgerStore) ReadAuction(id string) (*market.Auction, error) {
+	txn := bs.db.NewTransaction(false)
+	defer txn.Discard()
+
+	return bs.readAuction(txn, id)
+}
+
+// ListEndedAuctions returns the active auctions ended at the time
+func (bs *BadgerStore) ListEndedAuctions(until time.Time, limit int) ([]*market.Auction, error) {
+	txn := bs.db.NewTransaction(false)
+	defer txn.Discard()
+
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	opts.Prefix = []byte(prefixAuctionExpiry)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	var auctions []*market.Auction
+	for it.Seek(opts.Prefix); it.Valid(); it.Next() {
+		key := it.Item().Key()
+		if bytes.Compare(key[len(opts.Prefix):len(opts.Prefix)+8], tsToBytes(until)) > 0 {
+			break
+		}
+		id := string(key[len(opts.Prefix)+8:])
+		a, err := bs.readAuction(txn, id)
+		if err != nil {
+			return nil, err
+		}
+		auctions = append(auctions, a)
+		if len(auctions) == limit {
+			break
+		}
+	}
+	return auctions, nil
+}
+
+func (bs *BadgerStore) readAuction(txn *badger.Txn, id string) (*market.Auction, error) {
+	key := []byte(prefixAuctionPayload + id)
+	item, err := txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	val, err := item.ValueCopy(nil)
+	if err != nil {
+		return nil, err
+	}
+	var a market.Auction
+	err = mtg.MsgpackUnmarshal(val, &a)
+	return &a, err
+}
+
+func buildAuctionExpiryKey(a *market.Auction) []byte {
+	key := append([]byte(prefixAuctionExpiry), tsToBytes(a.EndedAt)...)
+	return append(key, a.Id...)
+}
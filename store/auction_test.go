@@ -0,0 +1,118 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MixinNetwork/nfo/market"
+	"github.com/MixinNetwork/nfo/nft"
+	"github.com/gofrs/uuid"
+)
+
+func TestAuctionSettlement(t *testing.T) {
+	bs, err := OpenBadger(context.Background(), t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bs.Close()
+
+	collection := uuid.FromStringOrNil("a0a9b2c5-2b7c-4b7e-9a3c-2e0b6c6d1f01")
+	seller := "4b188942-9fb0-4b99-b4be-e741a06d1ebf"
+	bidder := "dd655520-c919-4349-822f-af92fabdbdf4"
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	auction := func(id string, ended time.Time) *market.Auction {
+		return &market.Auction{
+			Id:         id,
+			Collection: collection.Bytes(),
+			TokenId:    id,
+			Seller:     seller,
+			AssetId:    nft.MintAssetId,
+			Reserve:    "1",
+			State:      market.AuctionStateActive,
+			EndedAt:    ended,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+	}
+
+	sold := auction("3c6d3b1a-58f9-3d8e-8f3c-0c5c1d2e4f10", now.Add(time.Hour))
+	unsold := auction("7d2f0c4e-1b8a-3f6d-9e5c-4a3b2c1d0e9f", now.Add(2*time.Hour))
+	for _, a := range []*market.Auction{sold, unsold} {
+		err = bs.WriteAuction(a, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a higher bid keeps the auction active until it ends
+	sold.Bidder, sold.Bid, sold.BidId = bidder, "2", "9a8b7c6d-5e4f-3a2b-8c1d-0e9f8a7b6c5d"
+	err = bs.WriteAuction(sold, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		until time.Time
+		ids   []string
+	}{
+		{"active", now.Add(time.Minute), nil},
+		{"first ended", now.Add(time.Hour), []string{sold.Id}},
+		{"both ended", now.Add(3 * time.Hour), []string{sold.Id, unsold.Id}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			auctions, err := bs.ListEndedAuctions(tc.until, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(auctions) != len(tc.ids) {
+				t.Fatalf("auctions %d, want %d", len(auctions), len(tc.ids))
+			}
+			for i, a := range auctions {
+				if a.Id != tc.ids[i] {
+					t.Fatalf("auction %s, want %s", a.Id, tc.ids[i])
+				}
+			}
+		})
+	}
+
+	// the auction with a bid is sold, and the other expires without a sale
+	sold.State, sold.UpdatedAt = market.AuctionStateSold, now.Add(time.Hour)
+	ev := &nft.Event{
+		Id:         "e1f2a3b4-c5d6-3e7f-8a9b-0c1d2e3f4a5b",
+		Kind:       nft.EventSale,
+		Collection: collection.String(),
+		TokenId:    sold.TokenId,
+		User:       sold.Bidder,
+		AssetId:    sold.AssetId,
+		Amount:     sold.Bid,
+		CreatedAt:  sold.UpdatedAt,
+	}
+	err = bs.WriteAuction(sold, []*nft.Event{ev})
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsold.State, unsold.UpdatedAt = market.AuctionStateExpired, now.Add(2*time.Hour)
+	err = bs.WriteAuction(unsold, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auctions, err := bs.ListEndedAuctions(now.Add(3*time.Hour), 10)
+	if err != nil || len(auctions) != 0 {
+		t.Fatalf("ListEndedAuctions %d %v, want 0", len(auctions), err)
+	}
+	a, err := bs.ReadAuction(sold.Id)
+	if err != nil || a.State != market.AuctionStateSold || a.Bidder != bidder {
+		t.Fatalf("ReadAuction %v %v", a, err)
+	}
+	s, err := bs.ReadCollectionStats(collection.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Sales != 1 || s.Volume[nft.MintAssetId] != "2" {
+		t.Fatalf("stats %d %v, want 1 2", s.Sales, s.Volume)
+	}
+}
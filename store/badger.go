@@ -2,9 +2,9 @@ package store
 
 import (
 	"context"
+	"log/slog"
 	"time"
 
-	"github.com/MixinNetwork/mixin/logger"
 	"github.com/dgraph-io/badger/v4"
 )
 
@@ -15,32 +15,61 @@ type BadgerStore struct {
 func OpenBadger(ctx context.Context, path string) (*BadgerStore, error) {
 	opts := badger.DefaultOptions(path)
 	db, err := badger.Open(opts)
+	if err != nil {
+		return nil, err
+	}
+	bs := &BadgerStore{
+		db: db,
+	}
+	err = bs.migrateTokenIds()
+	if err == nil {
+		err = bs.migrateCollectionBurned()
+	}
+	if err == nil {
+		err = bs.migrateUserMints()
+	}
+	if err == nil {
+		err = bs.migrateCollectionStats()
+	}
+	if err == nil {
+		err = bs.migrateCollectionSearch()
+	}
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
 
 	go func() {
 		for {
 			lsm, vlog := db.Size()
-			logger.Printf("Badger LSM %d VLOG %d\n", lsm, vlog)
+			slog.Info("Badger.Size", "lsm", lsm, "vlog", vlog)
 			if lsm > 1024*1024*8 || vlog > 1024*1024*32 {
 				err := db.RunValueLogGC(0.5)
-				logger.Printf("Badger RunValueLogGC %v\n", err)
+				slog.Info("Badger.RunValueLogGC", "error", err)
 			}
 			time.Sleep(5 * time.Minute)
 		}
 	}()
 
-	return &BadgerStore{
-		db: db,
-	}, err
+	return bs, nil
 }
 
 func (bs *BadgerStore) Close() error {
 	return bs.db.Close()
 }
 
+func (bs *BadgerStore) IsClosed() bool {
+	return bs.db.IsClosed()
+}
+
 func (bs *BadgerStore) Badger() *badger.DB {
 	return bs.db
 }
 
+func (bs *BadgerStore) Size() (int64, int64) {
+	return bs.db.Size()
+}
+
 func (bs *BadgerStore) WriteProperty(key, val []byte) error {
 	return bs.db.Update(func(txn *badger.Txn) error {
 		return txn.Set(key, val)
@@ -59,3 +88,20 @@ func (bs *BadgerStore) ReadProperty(key []byte) ([]byte, error) {
 	}
 	return item.ValueCopy(nil)
 }
+
+func (bs *BadgerStore) countKeys(prefix string) (int, error) {
+	txn := bs.db.NewTransaction(false)
+	defer txn.Discard()
+
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	opts.Prefix = []byte(prefix)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	var count int
+	for it.Seek(opts.Prefix); it.Valid(); it.Next() {
+		count += 1
+	}
+	return count, nil
+}
@@ -70,6 +70,11 @@ func (bs *BadgerStore) WriteCollectibleTransaction(traceId string, tx *mtg.Colle
 			}
 		}
 
+		err = bs.writeTransactionProvenance(txn, tx)
+		if err != nil {
+			return err
+		}
+
 		key = buildCollectibleTransactionTimedKey(tx)
 		return txn.Set(key, []byte{1})
 	})
@@ -127,6 +132,10 @@ func (bs *BadgerStore) ListCollectibleTransactions(state int, limit int) ([]*mtg
 	return txs, nil
 }
 
+func (bs *BadgerStore) CountCollectibleTransactions(state int) (int, error) {
+	return bs.countKeys(collectibleTransactionStatePrefix(state))
+}
+
 func (bs *BadgerStore) listCollectibleOutputs(prefix string, limit int) ([]*mtg.CollectibleOutput, error) {
 	txn := bs.db.NewTransaction(false)
 	defer txn.Discard()
@@ -178,6 +187,11 @@ func (bs *BadgerStore) writeCollectibleOutput(txn *badger.Txn, utxo *mtg.Collect
 		return err
 	}
 
+	err = bs.writeOutputProvenance(txn, utxo)
+	if err != nil {
+		return err
+	}
+
 	if traceId == "" {
 		return nil
 	}
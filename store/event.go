@@ -0,0 +1,94 @@
+package store
+
+import (
+	"encoding/binary"
+
+	"github.com/MixinNetwork/nfo/nft"
+	"github.com/MixinNetwork/trusted-group/mtg"
+	"github.com/dgraph-io/badger/v4"
+)
+
+const (
+	prefixEventPayload  = "EVENTS:PAYLOAD:"
+	prefixEventId       = "EVENTS:ID:"
+	propertyEventLatest = "EVENTS:SEQUENCE"
+)
+
+func (bs *BadgerStore) WriteEvent(ev *nft.Event) error {
+	return bs.db.Update(func(txn *badger.Txn) error {
+		return bs.writeEvent(txn, ev)
+	})
+}
+
+// ListEvents returns the events with sequence larger than offset
+func (bs *BadgerStore) ListEvents(offset uint64, limit int) ([]*nft.Event, error) {
+	txn := bs.db.NewTransaction(false)
+	defer txn.Discard()
+
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = []byte(prefixEventPayload)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	var events []*nft.Event
+	for it.Seek(buildEventKey(offset + 1)); it.Valid(); it.Next() {
+		val, err := it.Item().ValueCopy(nil)
+		if err != nil {
+			return nil, err
+		}
+		var ev nft.Event
+		err = mtg.MsgpackUnmarshal(val, &ev)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, &ev)
+		if len(events) == limit {
+			break
+		}
+	}
+	return events, nil
+}
+
+func (bs *BadgerStore) writeEvent(txn *badger.Txn, ev *nft.Event) error {
+	key := []byte(prefixEventId + ev.Id)
+	_, err := txn.Get(key)
+	if err == nil {
+		return nil
+	} else if err != badger.ErrKeyNotFound {
+		return err
+	}
+
+	seq, err := bs.readLatestEventSequence(txn)
+	if err != nil {
+		return err
+	}
+	ev.Sequence = seq + 1
+	val := binary.BigEndian.AppendUint64(nil, ev.Sequence)
+	err = txn.Set(key, val)
+	if err != nil {
+		return err
+	}
+	err = txn.Set([]byte(propertyEventLatest), val)
+	if err != nil {
+		return err
+	}
+	return txn.Set(buildEventKey(ev.Sequence), mtg.MsgpackMarshalPanic(ev))
+}
+
+func (bs *BadgerStore) readLatestEventSequence(txn *badger.Txn) (uint64, error) {
+	item, err := txn.Get([]byte(propertyEventLatest))
+	if err == badger.ErrKeyNotFound {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	val, err := item.ValueCopy(nil)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(val), nil
+}
+
+func buildEventKey(seq uint64) []byte {
+	return binary.BigEndian.AppendUint64([]byte(prefixEventPayload), seq)
+}
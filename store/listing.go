@@ -0,0 +1,114 @@
+package store
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/MixinNetwork/nfo/market"
+	"github.com/MixinNetwork/nfo/nft"
+	"github.com/MixinNetwork/trusted-group/mtg"
+	"github.com/dgraph-io/badger/v4"
+)
+
+const (
+	prefixListingPayload = "MARKET:LISTING:PAYLOAD:"
+	prefixListingExpiry  = "MARKET:LISTING:EXPIRY:"
+)
+
+func (bs *BadgerStore) WriteListing(l *market.Listing, events []*nft.Event) error {
+	return bs.db.Update(func(txn *badger.Txn) error {
+		old, err := bs.readListing(txn, l.Id)
+		if err != nil {
+			return err
+		}
+		if old != nil && old.State == market.ListingStateActive {
+			err = txn.Delete(buildListingExpiryKey(old))
+			if err != nil {
+				return err
+			}
+		}
+
+		key := []byte(prefixListingPayload + l.Id)
+		err = txn.Set(key, mtg.MsgpackMarshalPanic(l))
+		if err != nil {
+			return err
+		}
+		if l.State == market.ListingStateActive {
+			err = txn.Set(buildListingExpiryKey(l), []byte{1})
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, ev := range events {
+			err = bs.writeEvent(txn, ev)
+			if err != nil {
+				return err
+			}
+			err = bs.writeCollectionStats(txn, ev)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (bs *BadgerStore) ReadListing(id string) (*market.Listing, error) {
+	txn := bs.db.NewTransaction(false)
+	defer txn.Discard()
+
+	return bs.readListing(txn, id)
+}
+
+// ListExpiredListings returns the active listings expired at the time
+func (bs *BadgerStore) ListExpiredListings(until time.Time, limit int) ([]*market.Listing, error) {
+	txn := bs.db.NewTransaction(false)
+	defer txn.Discard()
+
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	opts.Prefix = []byte(prefixListingExpiry)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	var listings []*market.Listing
+	for it.Seek(opts.Prefix); it.Valid(); it.Next() {
+		key := it.Item().Key()
+		if bytes.Compare(key[len(opts.Prefix):len(opts.Prefix)+8], tsToBytes(until)) > 0 {
+			break
+		}
+		id := string(key[len(opts.Prefix)+8:])
+		l, err := bs.readListing(txn, id)
+		if err != nil {
+			return nil, err
+		}
+		listings = append(listings, l)
+		if len(listings) == limit {
+			break
+		}
+	}
+	return listings, nil
+}
+
+func (bs *BadgerStore) readListing(txn *badger.Txn, id string) (*market.Listing, error) {
+	key := []byte(prefixListingPayload + id)
+	item, err := txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	val, err := item.ValueCopy(nil)
+	if err != nil {
+		return nil, err
+	}
+	var l market.Listing
+	err = mtg.MsgpackUnmarshal(val, &l)
+	return &l, err
+}
+
+func buildListingExpiryKey(l *market.Listing) []byte {
+	key := append([]byte(prefixListingExpiry), tsToBytes(l.ExpiredAt)...)
+	return append(key, l.Id...)
+}
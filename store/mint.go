@@ -2,6 +2,8 @@ package store
 
 import (
 	"bytes"
+	"log/slog"
+	"time"
 
 	"github.com/MixinNetwork/trusted-group/mtg"
 	"github.com/MixinNetwork/nfo/nft"
@@ -11,44 +13,177 @@ import (
 const (
 	prefixMintCollectionPayload = "COLLECTIBLES:MINT:GROUP:"
 	prefixMintTokenPayload      = "COLLECTIBLES:MINT:TOKEN:"
+	prefixMintTokenBurn         = "COLLECTIBLES:MINT:BURN:"
+	prefixMintTokenAssign       = "COLLECTIBLES:MINT:ASSIGN:"
+	prefixMintCollectionSymbol  = "COLLECTIBLES:MINT:SYMBOL:"
+	prefixMintCollectionMinter  = "COLLECTIBLES:MINT:MINTER:"
+	propertyMintBurnedMigrated  = "COLLECTIBLES:MINT:MIGRATION:BURNED"
 )
 
-func (bs *BadgerStore) WriteMintToken(collection []byte, id []byte, user string) error {
+func (bs *BadgerStore) WriteMintToken(collection []byte, id []byte, user string, createdAt time.Time, traits []*nft.Trait, events []*nft.Event) error {
+	return bs.db.Update(func(txn *badger.Txn) error {
+		err := bs.writeMintToken(txn, collection, id, user, createdAt, 0, traits, false)
+		if err != nil {
+			return err
+		}
+		for _, ev := range events {
+			err = bs.writeEvent(txn, ev)
+			if err != nil {
+				return err
+			}
+			err = bs.writeCollectionStats(txn, ev)
+			if err != nil {
+				return err
+			}
+		}
+		return bs.deleteApprovals(txn, events)
+	})
+}
+
+// WriteSequenceToken mints the token of the sequence assigned by the MTG,
+// and records the token id assigned to the output
+func (bs *BadgerStore) WriteSequenceToken(collection []byte, sequence uint64, id []byte, user, utxoId string, createdAt time.Time, events []*nft.Event) error {
+	return bs.db.Update(func(txn *badger.Txn) error {
+		err := bs.writeMintToken(txn, collection, id, user, createdAt, sequence, nil, false)
+		if err != nil {
+			return err
+		}
+		err = txn.Set([]byte(prefixMintTokenAssign+utxoId), id)
+		if err != nil {
+			return err
+		}
+		for _, ev := range events {
+			err = bs.writeEvent(txn, ev)
+			if err != nil {
+				return err
+			}
+			err = bs.writeCollectionStats(txn, ev)
+			if err != nil {
+				return err
+			}
+		}
+		return bs.deleteApprovals(txn, events)
+	})
+}
+
+// ReadAssignedToken returns the token id assigned by the MTG to the output
+func (bs *BadgerStore) ReadAssignedToken(utxoId string) ([]byte, error) {
+	txn := bs.db.NewTransaction(false)
+	defer txn.Discard()
+
+	item, err := txn.Get([]byte(prefixMintTokenAssign + utxoId))
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return item.ValueCopy(nil)
+}
+
+func (bs *BadgerStore) WriteBurnToken(collection []byte, id []byte, ev *nft.Event) error {
 	return bs.db.Update(func(txn *badger.Txn) error {
 		old, err := bs.readMintToken(txn, collection, id)
 		if err != nil {
 			return err
-		} else if old != nil {
+		} else if old == nil {
 			panic(id)
+		} else if old.Burned {
+			return nil
 		}
 
+		key := append([]byte(prefixMintTokenBurn), collection...)
+		key = append(key, id...)
+		err = txn.Set(key, []byte{1})
+		if err != nil {
+			return err
+		}
+		err = bs.deleteTokenTraits(txn, collection, id, old.Traits)
+		if err != nil {
+			return err
+		}
 		og, err := bs.readMintCollection(txn, collection)
 		if err != nil {
 			return err
 		}
-		if og == nil {
-			og = &nft.Collection{
-				Key:         collection,
-				Creator:     user,
-				Circulation: 0,
-			}
+		og.Burned += 1
+		err = bs.writeMintCollection(txn, og)
+		if err != nil {
+			return err
 		}
-		if og.Creator != user && bytes.Compare(collection, mtg.NMDefaultCollectionKey) != 0 {
-			panic(og.Creator)
+		err = bs.writeBurnProvenance(txn, ev)
+		if err != nil {
+			return err
 		}
-		og.Circulation += 1
+		return bs.writeEvent(txn, ev)
+	})
+}
 
-		key := append([]byte(prefixMintCollectionPayload), collection...)
-		err = txn.Set(key, mtg.MsgpackMarshalPanic(og))
+func (bs *BadgerStore) WriteMintCollection(og *nft.Collection, events []*nft.Event) error {
+	return bs.db.Update(func(txn *badger.Txn) error {
+		err := bs.writeMintCollection(txn, og)
 		if err != nil {
 			return err
 		}
-		key = append([]byte(prefixMintTokenPayload), collection...)
-		key = append(key, id...)
-		return txn.Set(key, []byte{1})
+		for _, ev := range events {
+			err = bs.writeEvent(txn, ev)
+			if err != nil {
+				return err
+			}
+		}
+		return bs.deleteApprovals(txn, events)
+	})
+}
+
+// WriteMinterChange writes the minters and appends the change to the log
+func (bs *BadgerStore) WriteMinterChange(og *nft.Collection, change *nft.MinterChange, events []*nft.Event) error {
+	return bs.db.Update(func(txn *badger.Txn) error {
+		err := bs.writeMintCollection(txn, og)
+		if err != nil {
+			return err
+		}
+		key := append([]byte(prefixMintCollectionMinter), og.Key...)
+		key = append(key, tsToBytes(change.CreatedAt)...)
+		key = append(key, change.OutputId...)
+		err = txn.Set(key, mtg.MsgpackMarshalPanic(change))
+		if err != nil {
+			return err
+		}
+		for _, ev := range events {
+			err = bs.writeEvent(txn, ev)
+			if err != nil {
+				return err
+			}
+		}
+		return bs.deleteApprovals(txn, events)
 	})
 }
 
+// ListMinterChanges returns the minters log of the collection in time order
+func (bs *BadgerStore) ListMinterChanges(collection []byte) ([]*nft.MinterChange, error) {
+	txn := bs.db.NewTransaction(false)
+	defer txn.Discard()
+
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = append([]byte(prefixMintCollectionMinter), collection...)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	var changes []*nft.MinterChange
+	for it.Seek(opts.Prefix); it.Valid(); it.Next() {
+		val, err := it.Item().ValueCopy(nil)
+		if err != nil {
+			return nil, err
+		}
+		var c nft.MinterChange
+		err = mtg.MsgpackUnmarshal(val, &c)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, &c)
+	}
+	return changes, nil
+}
+
 func (bs *BadgerStore) ReadMintCollection(collection []byte) (*nft.Collection, error) {
 	txn := bs.db.NewTransaction(false)
 	defer txn.Discard()
@@ -56,6 +191,24 @@ func (bs *BadgerStore) ReadMintCollection(collection []byte) (*nft.Collection, e
 	return bs.readMintCollection(txn, collection)
 }
 
+// ReadCollectionBySymbol returns the collection registered with the symbol
+func (bs *BadgerStore) ReadCollectionBySymbol(symbol string) (*nft.Collection, error) {
+	txn := bs.db.NewTransaction(false)
+	defer txn.Discard()
+
+	item, err := txn.Get([]byte(prefixMintCollectionSymbol + symbol))
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	collection, err := item.ValueCopy(nil)
+	if err != nil {
+		return nil, err
+	}
+	return bs.readMintCollection(txn, collection)
+}
+
 func (bs *BadgerStore) ReadMintToken(collection, token []byte) (*nft.Token, error) {
 	txn := bs.db.NewTransaction(false)
 	defer txn.Discard()
@@ -63,6 +216,139 @@ func (bs *BadgerStore) ReadMintToken(collection, token []byte) (*nft.Token, erro
 	return bs.readMintToken(txn, collection, token)
 }
 
+func (bs *BadgerStore) ListMintCollections() ([]*nft.Collection, error) {
+	txn := bs.db.NewTransaction(false)
+	defer txn.Discard()
+
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = []byte(prefixMintCollectionPayload)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	var cs []*nft.Collection
+	for it.Seek(opts.Prefix); it.Valid(); it.Next() {
+		val, err := it.Item().ValueCopy(nil)
+		if err != nil {
+			return nil, err
+		}
+		var g nft.Collection
+		err = mtg.MsgpackUnmarshal(val, &g)
+		if err != nil {
+			return nil, err
+		}
+		cs = append(cs, &g)
+	}
+	return cs, nil
+}
+
+// writeMintToken mints the token by the user who can mint in the collection,
+// or by the buyer of the sale validated by the worker
+func (bs *BadgerStore) writeMintToken(txn *badger.Txn, collection, id []byte, user string, createdAt time.Time, sequence uint64, traits []*nft.Trait, sale bool) error {
+	old, err := bs.readMintToken(txn, collection, id)
+	if err != nil {
+		return err
+	} else if old != nil {
+		panic(id)
+	}
+
+	og, err := bs.readMintCollection(txn, collection)
+	if err != nil {
+		return err
+	}
+	if og == nil {
+		og = &nft.Collection{
+			Key:         collection,
+			Creator:     user,
+			Circulation: 0,
+		}
+	}
+	if !sale && !og.CanMint(user) {
+		panic(og.Creator)
+	}
+	err = txn.Delete(buildCollectionRankKey(og))
+	if err != nil {
+		return err
+	}
+	og.Circulation += 1
+	if sequence > 0 {
+		og.Sequence = sequence
+	}
+
+	key := append([]byte(prefixMintCollectionPayload), collection...)
+	err = txn.Set(key, mtg.MsgpackMarshalPanic(og))
+	if err != nil {
+		return err
+	}
+	err = txn.Set(buildCollectionRankKey(og), []byte{1})
+	if err != nil {
+		return err
+	}
+	err = bs.writeTokenId(txn, collection, id)
+	if err != nil {
+		return err
+	}
+	err = bs.writeUserMint(txn, &nft.UserMint{
+		User:       user,
+		Collection: collection,
+		Token:      id,
+		CreatedAt:  createdAt,
+	})
+	if err != nil {
+		return err
+	}
+	key = append([]byte(prefixMintTokenPayload), collection...)
+	key = append(key, id...)
+	if len(traits) == 0 {
+		return txn.Set(key, []byte{1})
+	}
+	err = txn.Set(key, mtg.MsgpackMarshalPanic(traits))
+	if err != nil {
+		return err
+	}
+	return bs.writeTokenTraits(txn, collection, id, traits)
+}
+
+// writeMintCollection writes the collection and indexes the unique symbol
+func (bs *BadgerStore) writeMintCollection(txn *badger.Txn, og *nft.Collection) error {
+	old, err := bs.readMintCollection(txn, og.Key)
+	if err != nil {
+		return err
+	}
+	if old != nil && old.Symbol != "" && old.Symbol != og.Symbol {
+		err = txn.Delete([]byte(prefixMintCollectionSymbol + old.Symbol))
+		if err != nil {
+			return err
+		}
+	}
+	if og.Symbol != "" {
+		key := []byte(prefixMintCollectionSymbol + og.Symbol)
+		item, err := txn.Get(key)
+		if err == nil {
+			val, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			if bytes.Compare(val, og.Key) != 0 {
+				panic(og.Symbol)
+			}
+		} else if err != badger.ErrKeyNotFound {
+			return err
+		}
+		err = txn.Set(key, og.Key)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = bs.writeCollectionSearch(txn, old, og)
+	if err != nil {
+		return err
+	}
+
+	key := append([]byte(prefixMintCollectionPayload), og.Key...)
+	return txn.Set(key, mtg.MsgpackMarshalPanic(og))
+}
+
 func (bs *BadgerStore) readMintCollection(txn *badger.Txn, collection []byte) (*nft.Collection, error) {
 	key := append([]byte(prefixMintCollectionPayload), collection...)
 	item, err := txn.Get(key)
@@ -83,14 +369,79 @@ func (bs *BadgerStore) readMintCollection(txn *badger.Txn, collection []byte) (*
 func (bs *BadgerStore) readMintToken(txn *badger.Txn, collection, id []byte) (*nft.Token, error) {
 	key := append([]byte(prefixMintTokenPayload), collection...)
 	key = append(key, id...)
-	_, err := txn.Get(key)
+	item, err := txn.Get(key)
 	if err == badger.ErrKeyNotFound {
 		return nil, nil
 	} else if err != nil {
 		return nil, err
 	}
-	return &nft.Token{
+	token := &nft.Token{
 		Collection: collection,
 		Key:        id,
-	}, nil
+	}
+	val, err := item.ValueCopy(nil)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Compare(val, []byte{1}) != 0 {
+		err = mtg.MsgpackUnmarshal(val, &token.Traits)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	key = append([]byte(prefixMintTokenBurn), collection...)
+	key = append(key, id...)
+	_, err = txn.Get(key)
+	if err == nil {
+		token.Burned = true
+	} else if err != badger.ErrKeyNotFound {
+		return nil, err
+	}
+	return token, nil
+}
+
+// migrateCollectionBurned counts the tokens burned before the collection
+// burned counter
+func (bs *BadgerStore) migrateCollectionBurned() error {
+	val, err := bs.ReadProperty([]byte(propertyMintBurnedMigrated))
+	if err != nil || len(val) > 0 {
+		return err
+	}
+
+	burned := make(map[string]int)
+	err = bs.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = []byte(prefixMintTokenBurn)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(opts.Prefix); it.Valid(); it.Next() {
+			key := it.Item().Key()[len(opts.Prefix):]
+			burned[string(key[:16])] += 1
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = bs.db.Update(func(txn *badger.Txn) error {
+		for collection, count := range burned {
+			og, err := bs.readMintCollection(txn, []byte(collection))
+			if err != nil {
+				return err
+			}
+			og.Burned = count
+			key := append([]byte(prefixMintCollectionPayload), og.Key...)
+			err = txn.Set(key, mtg.MsgpackMarshalPanic(og))
+			if err != nil {
+				return err
+			}
+		}
+		return txn.Set([]byte(propertyMintBurnedMigrated), []byte{1})
+	})
+	slog.Info("BadgerStore.migrateCollectionBurned", "collections", len(burned))
+	return err
 }
@@ -0,0 +1,114 @@
+package store
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/MixinNetwork/nfo/market"
+	"github.com/MixinNetwork/nfo/nft"
+	"github.com/MixinNetwork/trusted-group/mtg"
+	"github.com/dgraph-io/badger/v4"
+)
+
+const (
+	prefixOfferPayload = "MARKET:OFFER:PAYLOAD:"
+	prefixOfferExpiry  = "MARKET:OFFER:EXPIRY:"
+)
+
+func (bs *BadgerStore) WriteOffer(o *market.Offer, events []*nft.Event) error {
+	return bs.db.Update(func(txn *badger.Txn) error {
+		old, err := bs.readOffer(txn, o.Id)
+		if err != nil {
+			return err
+		}
+		if old != nil && old.State == market.OfferStateActive {
+			err = txn.Delete(buildOfferExpiryKey(old))
+			if err != nil {
+				return err
+			}
+		}
+
+		key := []byte(prefixOfferPayload + o.Id)
+		err = txn.Set(key, mtg.MsgpackMarshalPanic(o))
+		if err != nil {
+			return err
+		}
+		if o.State == market.OfferStateActive {
+			err = txn.Set(buildOfferExpiryKey(o), []byte{1})
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, ev := range events {
+			err = bs.writeEvent(txn, ev)
+			if err != nil {
+				return err
+			}
+			err = bs.writeCollectionStats(txn, ev)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (bs *BadgerStore) ReadOffer(id string) (*market.Offer, error) {
+	txn := bs.db.NewTransaction(false)
+	defer txn.Discard()
+
+	return bs.readOffer(txn, id)
+}
+
+// ListExpiredOffers returns the active offers expired at the time
+func (bs *BadgerStore) ListExpiredOffers(until time.Time, limit int) ([]*market.Offer, error) {
+	txn := bs.db.NewTransaction(false)
+	defer txn.Discard()
+
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	opts.Prefix = []byte(prefixOfferExpiry)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	var offers []*market.Offer
+	for it.Seek(opts.Prefix); it.Valid(); it.Next() {
+		key := it.Item().Key()
+		if bytes.Compare(key[len(opts.Prefix):len(opts.Prefix)+8], tsToBytes(until)) > 0 {
+			break
+		}
+		id := string(key[len(opts.Prefix)+8:])
+		o, err := bs.readOffer(txn, id)
+		if err != nil {
+			return nil, err
+		}
+		offers = append(offers, o)
+		if len(offers) == limit {
+			break
+		}
+	}
+	return offers, nil
+}
+
+func (bs *BadgerStore) readOffer(txn *badger.Txn, id string) (*market.Offer, error) {
+	key := []byte(prefixOfferPayload + id)
+	item, err := txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	val, err := item.ValueCopy(nil)
+	if err != nil {
+		return nil, err
+	}
+	var o market.Offer
+	err = mtg.MsgpackUnmarshal(val, &o)
+	return &o, err
+}
+
+func buildOfferExpiryKey(o *market.Offer) []byte {
+	key := append([]byte(prefixOfferExpiry), tsToBytes(o.ExpiredAt)...)
+	return append(key, o.Id...)
+}
@@ -39,6 +39,30 @@ func (bs *BadgerStore) ListOutputsForAsset(groupId, state, assetId string, limit
 	return bs.listOutputs(prefix, limit)
 }
 
+// CountOutputsForState counts the outputs in the state by their asset ids
+func (bs *BadgerStore) CountOutputsForState(state string) (map[string]int, error) {
+	txn := bs.db.NewTransaction(false)
+	defer txn.Discard()
+
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	opts.Prefix = []byte(prefixOutputGroupAsset + state)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	assets := make(map[string]int)
+	for it.Seek(opts.Prefix); it.Valid(); it.Next() {
+		key := it.Item().Key()
+		// prefix + state + asset id + (group id) + timestamp + uuid
+		if len(key) < len(opts.Prefix)+36+8+36 {
+			continue
+		}
+		asset := string(key[len(opts.Prefix) : len(opts.Prefix)+36])
+		assets[asset] += 1
+	}
+	return assets, nil
+}
+
 func (bs *BadgerStore) listOutputs(prefix string, limit int) ([]*mtg.Output, error) {
 	txn := bs.db.NewTransaction(false)
 	defer txn.Discard()
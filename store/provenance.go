@@ -0,0 +1,165 @@
+package store
+
+import (
+	"time"
+
+	"github.com/MixinNetwork/nfo/nft"
+	"github.com/MixinNetwork/trusted-group/mtg"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/fox-one/mixin-sdk-go"
+)
+
+const (
+	prefixCollectibleProvenance        = "COLLECTIBLES:PROVENANCE:"
+	prefixCollectibleProvenancePending = "COLLECTIBLES:PENDING:PROVENANCE:"
+)
+
+// ListTokenProvenance lists all the state changes of the token in time order
+func (bs *BadgerStore) ListTokenProvenance(tokenId string) ([]*nft.Provenance, error) {
+	txn := bs.db.NewTransaction(false)
+	defer txn.Discard()
+
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = []byte(prefixCollectibleProvenance + tokenId)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	var history []*nft.Provenance
+	for it.Seek(opts.Prefix); it.Valid(); it.Next() {
+		val, err := it.Item().ValueCopy(nil)
+		if err != nil {
+			return nil, err
+		}
+		var p nft.Provenance
+		err = mtg.MsgpackUnmarshal(val, &p)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, &p)
+	}
+	return history, nil
+}
+
+// writeOutputProvenance records the token received by the MTG
+func (bs *BadgerStore) writeOutputProvenance(txn *badger.Txn, out *mtg.CollectibleOutput) error {
+	if out.TokenId == mtg.CollectibleMetaTokenId {
+		return nil
+	}
+	return bs.writeProvenance(txn, &nft.Provenance{
+		TokenId:   out.TokenId,
+		Kind:      nft.ProvenanceReceive,
+		Hash:      out.TransactionHash.String(),
+		Users:     out.Senders,
+		Threshold: int(out.SendersThreshold),
+		CreatedAt: out.CreatedAt,
+	})
+}
+
+// writeTransactionProvenance keeps the receivers at signing, which the
+// drained transaction lacks, and records them once in the snapshot
+func (bs *BadgerStore) writeTransactionProvenance(txn *badger.Txn, tx *mtg.CollectibleTransaction) error {
+	key := []byte(prefixCollectibleProvenancePending + tx.TraceId)
+	if tx.State == mtg.TransactionStateSigning {
+		p := &nft.Provenance{
+			TokenId:   tx.TokenId,
+			Kind:      nft.ProvenanceSend,
+			Users:     tx.Receivers,
+			Threshold: tx.Threshold,
+		}
+		if p.TokenId == "" {
+			nfm, err := mtg.DecodeNFOMemo(tx.NFO)
+			if err != nil || !nfm.WillMint() {
+				return err
+			}
+			p.TokenId = nft.BuildTokenId(nfm.Collection, nfm.Token)
+			p.Kind = nft.ProvenanceMint
+		}
+		return txn.Set(key, mtg.MsgpackMarshalPanic(p))
+	}
+	if tx.State != mtg.TransactionStateSnapshot {
+		return nil
+	}
+
+	item, err := txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	val, err := item.ValueCopy(nil)
+	if err != nil {
+		return err
+	}
+	var p nft.Provenance
+	err = mtg.MsgpackUnmarshal(val, &p)
+	if err != nil {
+		return err
+	}
+
+	p.CreatedAt, err = bs.readTransactionSpentAt(txn, tx.TraceId)
+	if err != nil || p.CreatedAt.IsZero() {
+		return err
+	}
+	p.Hash = tx.Hash.String()
+	err = txn.Delete(key)
+	if err != nil {
+		return err
+	}
+	return bs.writeProvenance(txn, &p)
+}
+
+// readTransactionSpentAt returns the network time the inputs were spent
+func (bs *BadgerStore) readTransactionSpentAt(txn *badger.Txn, traceId string) (time.Time, error) {
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	opts.Prefix = []byte(prefixCollectibleOutputTransaction + traceId)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	var spentAt time.Time
+	for it.Seek(opts.Prefix); it.Valid(); it.Next() {
+		key := it.Item().Key()
+		out, err := bs.readCollectibleOutput(txn, string(key[len(opts.Prefix)+8:]))
+		if err != nil {
+			return spentAt, err
+		}
+		if out.UpdatedAt.After(spentAt) {
+			spentAt = out.UpdatedAt
+		}
+	}
+	return spentAt, nil
+}
+
+// writeBurnProvenance records the burn with the hash of the burn deposit
+func (bs *BadgerStore) writeBurnProvenance(txn *badger.Txn, ev *nft.Event) error {
+	opts := badger.DefaultIteratorOptions
+	opts.Reverse = true
+	prefix := []byte(prefixCollectibleOutputToken + mixin.UTXOStateUnspent + ev.TokenId)
+	opts.Prefix = prefix
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	p := &nft.Provenance{
+		TokenId:   ev.TokenId,
+		Kind:      nft.ProvenanceBurn,
+		Users:     []string{ev.User},
+		Threshold: 1,
+		CreatedAt: ev.CreatedAt,
+	}
+	it.Seek(append(prefix, 0xff))
+	if it.Valid() {
+		key := it.Item().Key()
+		out, err := bs.readCollectibleOutput(txn, string(key[len(prefix)+8:]))
+		if err != nil {
+			return err
+		}
+		p.Hash = out.TransactionHash.String()
+	}
+	return bs.writeProvenance(txn, p)
+}
+
+func (bs *BadgerStore) writeProvenance(txn *badger.Txn, p *nft.Provenance) error {
+	key := append([]byte(prefixCollectibleProvenance+p.TokenId), tsToBytes(p.CreatedAt)...)
+	key = append(key, p.Kind+p.Hash...)
+	return txn.Set(key, mtg.MsgpackMarshalPanic(p))
+}
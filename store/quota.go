@@ -0,0 +1,100 @@
+package store
+
+import (
+	"time"
+
+	"github.com/MixinNetwork/nfo/nft"
+	"github.com/MixinNetwork/trusted-group/mtg"
+	"github.com/dgraph-io/badger/v4"
+)
+
+const (
+	prefixMintQuota = "COLLECTIBLES:MINT:QUOTA:"
+	prefixMintLimit = "COLLECTIBLES:MINT:LIMIT:"
+)
+
+// WriteDefaultMintToken mints the token in the default collection, and
+// updates the mint quota of the user in the same transaction
+func (bs *BadgerStore) WriteDefaultMintToken(id []byte, user string, createdAt time.Time, traits []*nft.Trait, quota *nft.MintQuota, events []*nft.Event) error {
+	return bs.db.Update(func(txn *badger.Txn) error {
+		err := bs.writeMintToken(txn, mtg.NMDefaultCollectionKey, id, user, createdAt, 0, traits, false)
+		if err != nil {
+			return err
+		}
+		err = txn.Set([]byte(prefixMintQuota+user), mtg.MsgpackMarshalPanic(quota))
+		if err != nil {
+			return err
+		}
+		for _, ev := range events {
+			err = bs.writeEvent(txn, ev)
+			if err != nil {
+				return err
+			}
+			err = bs.writeCollectionStats(txn, ev)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (bs *BadgerStore) ReadMintQuota(user string) (*nft.MintQuota, error) {
+	txn := bs.db.NewTransaction(false)
+	defer txn.Discard()
+
+	item, err := txn.Get([]byte(prefixMintQuota + user))
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	val, err := item.ValueCopy(nil)
+	if err != nil {
+		return nil, err
+	}
+	var q nft.MintQuota
+	err = mtg.MsgpackUnmarshal(val, &q)
+	return &q, err
+}
+
+func (bs *BadgerStore) WriteMintLimit(limit *nft.MintLimit, events []*nft.Event) error {
+	return bs.db.Update(func(txn *badger.Txn) error {
+		key := append([]byte(prefixMintLimit), tsToBytes(limit.ActiveAt)...)
+		err := txn.Set(key, mtg.MsgpackMarshalPanic(limit))
+		if err != nil {
+			return err
+		}
+		for _, ev := range events {
+			err = bs.writeEvent(txn, ev)
+			if err != nil {
+				return err
+			}
+		}
+		return bs.deleteApprovals(txn, events)
+	})
+}
+
+// ReadMintLimit returns the latest limit activated at or before the time
+func (bs *BadgerStore) ReadMintLimit(at time.Time) (*nft.MintLimit, error) {
+	txn := bs.db.NewTransaction(false)
+	defer txn.Discard()
+
+	opts := badger.DefaultIteratorOptions
+	opts.Reverse = true
+	opts.Prefix = []byte(prefixMintLimit)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	it.Seek(append([]byte(prefixMintLimit), tsToBytes(at)...))
+	if !it.Valid() {
+		return nil, nil
+	}
+	val, err := it.Item().ValueCopy(nil)
+	if err != nil {
+		return nil, err
+	}
+	var l nft.MintLimit
+	err = mtg.MsgpackUnmarshal(val, &l)
+	return &l, err
+}
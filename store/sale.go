@@ -0,0 +1,131 @@
+package store
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/MixinNetwork/nfo/nft"
+	"github.com/MixinNetwork/trusted-group/mtg"
+	"github.com/dgraph-io/badger/v4"
+)
+
+const (
+	prefixMintSalePayload = "COLLECTIBLES:SALE:PAYLOAD:"
+	prefixMintSaleCount   = "COLLECTIBLES:SALE:COUNT:"
+)
+
+// WriteMintSale writes the sale along with the collection, which may be
+// started by the sale, and the events in the same transaction
+func (bs *BadgerStore) WriteMintSale(og *nft.Collection, s *nft.Sale, events []*nft.Event) error {
+	return bs.db.Update(func(txn *badger.Txn) error {
+		err := bs.writeMintCollection(txn, og)
+		if err != nil {
+			return err
+		}
+		for _, ev := range events {
+			err = bs.writeEvent(txn, ev)
+			if err != nil {
+				return err
+			}
+		}
+		err = bs.deleteApprovals(txn, events)
+		if err != nil {
+			return err
+		}
+		key := append([]byte(prefixMintSalePayload), s.Collection...)
+		return txn.Set(key, mtg.MsgpackMarshalPanic(s))
+	})
+}
+
+func (bs *BadgerStore) ReadMintSale(collection []byte) (*nft.Sale, error) {
+	txn := bs.db.NewTransaction(false)
+	defer txn.Discard()
+
+	return bs.readMintSale(txn, collection)
+}
+
+func (bs *BadgerStore) ReadMintSaleCount(collection []byte, user string) (int, error) {
+	txn := bs.db.NewTransaction(false)
+	defer txn.Discard()
+
+	return bs.readMintSaleCount(txn, collection, user)
+}
+
+// WriteSaleToken mints the token purchased by the user, and updates the
+// sale and the user counters together
+func (bs *BadgerStore) WriteSaleToken(s *nft.Sale, sequence uint64, id []byte, user, utxoId string, createdAt time.Time, events []*nft.Event) error {
+	return bs.db.Update(func(txn *badger.Txn) error {
+		err := bs.writeMintToken(txn, s.Collection, id, user, createdAt, sequence, nil, true)
+		if err != nil {
+			return err
+		}
+
+		s.Minted += 1
+		key := append([]byte(prefixMintSalePayload), s.Collection...)
+		err = txn.Set(key, mtg.MsgpackMarshalPanic(s))
+		if err != nil {
+			return err
+		}
+		count, err := bs.readMintSaleCount(txn, s.Collection, user)
+		if err != nil {
+			return err
+		}
+		val := binary.BigEndian.AppendUint64(nil, uint64(count+1))
+		err = txn.Set(buildMintSaleCountKey(s.Collection, user), val)
+		if err != nil {
+			return err
+		}
+		err = txn.Set([]byte(prefixMintTokenAssign+utxoId), id)
+		if err != nil {
+			return err
+		}
+
+		for _, ev := range events {
+			err = bs.writeEvent(txn, ev)
+			if err != nil {
+				return err
+			}
+			err = bs.writeCollectionStats(txn, ev)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (bs *BadgerStore) readMintSale(txn *badger.Txn, collection []byte) (*nft.Sale, error) {
+	key := append([]byte(prefixMintSalePayload), collection...)
+	item, err := txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	val, err := item.ValueCopy(nil)
+	if err != nil {
+		return nil, err
+	}
+	var s nft.Sale
+	err = mtg.MsgpackUnmarshal(val, &s)
+	return &s, err
+}
+
+func (bs *BadgerStore) readMintSaleCount(txn *badger.Txn, collection []byte, user string) (int, error) {
+	item, err := txn.Get(buildMintSaleCountKey(collection, user))
+	if err == badger.ErrKeyNotFound {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	val, err := item.ValueCopy(nil)
+	if err != nil {
+		return 0, err
+	}
+	return int(binary.BigEndian.Uint64(val)), nil
+}
+
+func buildMintSaleCountKey(collection []byte, user string) []byte {
+	key := append([]byte(prefixMintSaleCount), collection...)
+	return append(key, user...)
+}
@@ -0,0 +1,163 @@
+package store
+
+import (
+	"encoding/binary"
+	"log/slog"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/MixinNetwork/nfo/nft"
+	"github.com/dgraph-io/badger/v4"
+)
+
+const (
+	prefixCollectionSearch           = "COLLECTIBLES:SEARCH:TERM:"
+	prefixCollectionRank             = "COLLECTIBLES:SEARCH:RANK:"
+	propertyCollectionSearchMigrated = "COLLECTIBLES:SEARCH:MIGRATION:RANK"
+
+	collectionSearchTermMaxLength = 32
+	collectionSearchTermsMax      = 8
+)
+
+// SearchCollections ranks the collections matching every query prefix
+func (bs *BadgerStore) SearchCollections(query string, limit int) ([]*nft.Collection, error) {
+	terms := buildSearchTerms(query)
+	if len(terms) == 0 || len(terms) > collectionSearchTermsMax {
+		return nil, nil
+	}
+
+	txn := bs.db.NewTransaction(false)
+	defer txn.Discard()
+
+	var matches map[string]bool
+	for _, term := range terms {
+		found, err := bs.searchCollectionTerm(txn, term)
+		if err != nil {
+			return nil, err
+		}
+		if matches != nil {
+			for k := range found {
+				if !matches[k] {
+					delete(found, k)
+				}
+			}
+		}
+		matches = found
+	}
+
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	opts.Prefix = []byte(prefixCollectionRank)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	var cs []*nft.Collection
+	for it.Seek(opts.Prefix); it.Valid() && len(cs) < min(limit, len(matches)); it.Next() {
+		key := it.Item().KeyCopy(nil)
+		if !matches[string(key[len(key)-16:])] {
+			continue
+		}
+		og, err := bs.readMintCollection(txn, key[len(key)-16:])
+		if err != nil {
+			return nil, err
+		}
+		cs = append(cs, og)
+	}
+	return cs, nil
+}
+
+func (bs *BadgerStore) searchCollectionTerm(txn *badger.Txn, term string) (map[string]bool, error) {
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	opts.Prefix = []byte(prefixCollectionSearch + term)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	found := make(map[string]bool)
+	for it.Seek(opts.Prefix); it.Valid(); it.Next() {
+		key := it.Item().Key()
+		found[string(key[len(key)-16:])] = true
+	}
+	return found, nil
+}
+
+// writeCollectionSearch replaces the terms and rank of the old collection
+func (bs *BadgerStore) writeCollectionSearch(txn *badger.Txn, old, og *nft.Collection) error {
+	if old != nil {
+		err := txn.Delete(buildCollectionRankKey(old))
+		if err != nil {
+			return err
+		}
+		for _, term := range buildCollectionSearchTerms(old) {
+			err := txn.Delete(buildCollectionSearchKey(term, old.Key))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	for _, term := range buildCollectionSearchTerms(og) {
+		err := txn.Set(buildCollectionSearchKey(term, og.Key), []byte{1})
+		if err != nil {
+			return err
+		}
+	}
+	return txn.Set(buildCollectionRankKey(og), []byte{1})
+}
+
+// migrateCollectionSearch indexes the collections before the search index
+func (bs *BadgerStore) migrateCollectionSearch() error {
+	val, err := bs.ReadProperty([]byte(propertyCollectionSearchMigrated))
+	if err != nil || len(val) > 0 {
+		return err
+	}
+	cs, err := bs.ListMintCollections()
+	if err != nil {
+		return err
+	}
+	for _, og := range cs {
+		err = bs.db.Update(func(txn *badger.Txn) error {
+			return bs.writeCollectionSearch(txn, nil, og)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	slog.Info("BadgerStore.migrateCollectionSearch", "collections", len(cs))
+	return bs.WriteProperty([]byte(propertyCollectionSearchMigrated), []byte{1})
+}
+
+func buildCollectionSearchTerms(og *nft.Collection) []string {
+	return buildSearchTerms(og.Name + " " + og.Symbol)
+}
+
+// buildSearchTerms splits the text into unique lower case words
+func buildSearchTerms(text string) []string {
+	var terms []string
+	filter := make(map[string]bool)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, w := range words {
+		if len(w) > collectionSearchTermMaxLength {
+			w = strings.ToValidUTF8(w[:collectionSearchTermMaxLength], "")
+		}
+		if filter[w] {
+			continue
+		}
+		filter[w] = true
+		terms = append(terms, w)
+	}
+	return terms
+}
+
+// buildCollectionRankKey orders by the circulation descending, then the key
+func buildCollectionRankKey(og *nft.Collection) []byte {
+	key := binary.BigEndian.AppendUint64([]byte(prefixCollectionRank), math.MaxUint64-uint64(og.Circulation))
+	return append(key, og.Key...)
+}
+
+func buildCollectionSearchKey(term string, collection []byte) []byte {
+	key := append([]byte(prefixCollectionSearch+term), 0)
+	return append(key, collection...)
+}
@@ -0,0 +1,163 @@
+package store
+
+import (
+	"encoding/binary"
+	"log/slog"
+
+	"github.com/MixinNetwork/nfo/nft"
+	"github.com/MixinNetwork/trusted-group/mtg"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/gofrs/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	prefixCollectionStats           = "COLLECTIBLES:STATS:PAYLOAD:"
+	prefixCollectionStatsMinter     = "COLLECTIBLES:STATS:MINTER:"
+	propertyCollectionStatsMigrated = "COLLECTIBLES:STATS:MIGRATION"
+)
+
+func (bs *BadgerStore) ReadCollectionStats(collection []byte) (*nft.CollectionStats, error) {
+	txn := bs.db.NewTransaction(false)
+	defer txn.Discard()
+
+	return bs.readCollectionStats(txn, collection)
+}
+
+// writeCollectionStats aggregates the mint, sale or purchase event
+func (bs *BadgerStore) writeCollectionStats(txn *badger.Txn, ev *nft.Event) error {
+	switch ev.Kind {
+	case nft.EventMint, nft.EventSale, nft.EventPurchase:
+	default:
+		return nil
+	}
+	collection := uuid.FromStringOrNil(ev.Collection).Bytes()
+	s, err := bs.readCollectionStats(txn, collection)
+	if err != nil {
+		return err
+	}
+
+	switch ev.Kind {
+	case nft.EventMint:
+		if s.FirstMintAt.IsZero() {
+			s.FirstMintAt = ev.CreatedAt
+		}
+		s.LastMintAt = ev.CreatedAt
+		// the purchase proceeds are in the purchase event
+		if ev.AssetId == nft.MintAssetId {
+			addStatsAmount(s.Fees, ev.AssetId, ev.Amount)
+		}
+		key := append([]byte(prefixCollectionStatsMinter), collection...)
+		key = append(key, ev.User...)
+		_, err := txn.Get(key)
+		if err == badger.ErrKeyNotFound {
+			s.Minters += 1
+			err = txn.Set(key, []byte{1})
+		}
+		if err != nil {
+			return err
+		}
+	case nft.EventSale:
+		s.Sales += 1
+		addStatsAmount(s.Volume, ev.AssetId, ev.Amount)
+	case nft.EventPurchase:
+		s.Purchases += 1
+		addStatsAmount(s.Proceeds, ev.AssetId, ev.Amount)
+	}
+
+	key := append([]byte(prefixCollectionStats), collection...)
+	return txn.Set(key, mtg.MsgpackMarshalPanic(s))
+}
+
+// readCollectionStats takes the minted and burned counts from the collection
+func (bs *BadgerStore) readCollectionStats(txn *badger.Txn, collection []byte) (*nft.CollectionStats, error) {
+	og, err := bs.readMintCollection(txn, collection)
+	if err != nil {
+		return nil, err
+	}
+	s := &nft.CollectionStats{
+		Collection: collection,
+		Fees:       make(map[string]string),
+		Volume:     make(map[string]string),
+		Proceeds:   make(map[string]string),
+	}
+	key := append([]byte(prefixCollectionStats), collection...)
+	item, err := txn.Get(key)
+	if err == nil {
+		val, err := item.ValueCopy(nil)
+		if err != nil {
+			return nil, err
+		}
+		err = mtg.MsgpackUnmarshal(val, s)
+		if err != nil {
+			return nil, err
+		}
+	} else if err != badger.ErrKeyNotFound {
+		return nil, err
+	}
+	if og != nil {
+		s.Minted, s.Burned = og.Circulation, og.Burned
+	}
+	if s.Fees == nil {
+		s.Fees = make(map[string]string)
+	}
+	if s.Volume == nil {
+		s.Volume = make(map[string]string)
+	}
+	if s.Proceeds == nil {
+		s.Proceeds = make(map[string]string)
+	}
+	return s, nil
+}
+
+// migrateCollectionStats aggregates the older events, resuming by sequence
+func (bs *BadgerStore) migrateCollectionStats() error {
+	val, err := bs.ReadProperty([]byte(propertyCollectionStatsMigrated))
+	if err != nil || len(val) == 1 {
+		return err
+	}
+	var offset uint64
+	if len(val) == 8 {
+		offset = binary.BigEndian.Uint64(val)
+	}
+
+	for {
+		events, err := bs.ListEvents(offset, 1000)
+		if err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			break
+		}
+		err = bs.db.Update(func(txn *badger.Txn) error {
+			for _, ev := range events {
+				err := bs.writeCollectionStats(txn, ev)
+				if err != nil {
+					return err
+				}
+			}
+			offset = events[len(events)-1].Sequence
+			val := binary.BigEndian.AppendUint64(nil, offset)
+			return txn.Set([]byte(propertyCollectionStatsMigrated), val)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	slog.Info("BadgerStore.migrateCollectionStats", "events", offset)
+	return bs.WriteProperty([]byte(propertyCollectionStatsMigrated), []byte{1})
+}
+
+func addStatsAmount(totals map[string]string, assetId, amount string) {
+	if assetId == "" {
+		return
+	}
+	a, err := decimal.NewFromString(amount)
+	if err != nil {
+		panic(amount)
+	}
+	if t, ok := totals[assetId]; ok {
+		a = a.Add(decimal.RequireFromString(t))
+	}
+	totals[assetId] = a.String()
+}
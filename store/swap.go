@@ -0,0 +1,152 @@
+package store
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/MixinNetwork/nfo/market"
+	"github.com/MixinNetwork/nfo/nft"
+	"github.com/MixinNetwork/trusted-group/mtg"
+	"github.com/dgraph-io/badger/v4"
+)
+
+const (
+	prefixSwapPayload = "MARKET:SWAP:PAYLOAD:"
+	prefixSwapExpiry  = "MARKET:SWAP:EXPIRY:"
+	prefixSwapMatch   = "MARKET:SWAP:MATCH:"
+)
+
+func (bs *BadgerStore) WriteSwaps(swaps []*market.Swap, events []*nft.Event) error {
+	return bs.db.Update(func(txn *badger.Txn) error {
+		for _, s := range swaps {
+			err := bs.writeSwap(txn, s)
+			if err != nil {
+				return err
+			}
+		}
+		for _, ev := range events {
+			err := bs.writeEvent(txn, ev)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (bs *BadgerStore) ReadSwap(id string) (*market.Swap, error) {
+	txn := bs.db.NewTransaction(false)
+	defer txn.Discard()
+
+	return bs.readSwap(txn, id)
+}
+
+// ReadActiveSwap returns the earliest active swap of the owner token, which
+// wants the token of the counterparty
+func (bs *BadgerStore) ReadActiveSwap(owner, tokenId, counterparty, want string) (*market.Swap, error) {
+	txn := bs.db.NewTransaction(false)
+	defer txn.Discard()
+
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	opts.Prefix = []byte(prefixSwapMatch + owner + tokenId + counterparty + want)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	it.Seek(opts.Prefix)
+	if !it.Valid() {
+		return nil, nil
+	}
+	key := it.Item().Key()
+	id := string(key[len(opts.Prefix)+8:])
+	return bs.readSwap(txn, id)
+}
+
+// ListExpiredSwaps returns the active swaps expired at the time
+func (bs *BadgerStore) ListExpiredSwaps(until time.Time, limit int) ([]*market.Swap, error) {
+	txn := bs.db.NewTransaction(false)
+	defer txn.Discard()
+
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	opts.Prefix = []byte(prefixSwapExpiry)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	var swaps []*market.Swap
+	for it.Seek(opts.Prefix); it.Valid(); it.Next() {
+		key := it.Item().Key()
+		if bytes.Compare(key[len(opts.Prefix):len(opts.Prefix)+8], tsToBytes(until)) > 0 {
+			break
+		}
+		id := string(key[len(opts.Prefix)+8:])
+		s, err := bs.readSwap(txn, id)
+		if err != nil {
+			return nil, err
+		}
+		swaps = append(swaps, s)
+		if len(swaps) == limit {
+			break
+		}
+	}
+	return swaps, nil
+}
+
+func (bs *BadgerStore) writeSwap(txn *badger.Txn, s *market.Swap) error {
+	old, err := bs.readSwap(txn, s.Id)
+	if err != nil {
+		return err
+	}
+	if old != nil && old.State == market.SwapStateActive {
+		err = txn.Delete(buildSwapExpiryKey(old))
+		if err != nil {
+			return err
+		}
+		err = txn.Delete(buildSwapMatchKey(old))
+		if err != nil {
+			return err
+		}
+	}
+
+	key := []byte(prefixSwapPayload + s.Id)
+	err = txn.Set(key, mtg.MsgpackMarshalPanic(s))
+	if err != nil {
+		return err
+	}
+	if s.State != market.SwapStateActive {
+		return nil
+	}
+	err = txn.Set(buildSwapExpiryKey(s), []byte{1})
+	if err != nil {
+		return err
+	}
+	return txn.Set(buildSwapMatchKey(s), []byte{1})
+}
+
+func (bs *BadgerStore) readSwap(txn *badger.Txn, id string) (*market.Swap, error) {
+	key := []byte(prefixSwapPayload + id)
+	item, err := txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	val, err := item.ValueCopy(nil)
+	if err != nil {
+		return nil, err
+	}
+	var s market.Swap
+	err = mtg.MsgpackUnmarshal(val, &s)
+	return &s, err
+}
+
+func buildSwapExpiryKey(s *market.Swap) []byte {
+	key := append([]byte(prefixSwapExpiry), tsToBytes(s.ExpiredAt)...)
+	return append(key, s.Id...)
+}
+
+func buildSwapMatchKey(s *market.Swap) []byte {
+	key := []byte(prefixSwapMatch + s.Owner + s.TokenId + s.Counterparty + s.Want)
+	key = append(key, tsToBytes(s.CreatedAt)...)
+	return append(key, s.Id...)
+}
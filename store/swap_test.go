@@ -0,0 +1,121 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MixinNetwork/nfo/market"
+	"github.com/MixinNetwork/nfo/nft"
+)
+
+func TestSwapMatching(t *testing.T) {
+	bs, err := OpenBadger(context.Background(), t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bs.Close()
+
+	alice := "4b188942-9fb0-4b99-b4be-e741a06d1ebf"
+	bob := "dd655520-c919-4349-822f-af92fabdbdf4"
+	carol := "c6d0c728-2624-429b-8e0d-d9d19b6592fa"
+	red := "a0a9b2c5-2b7c-4b7e-9a3c-2e0b6c6d1f01"
+	blue := "0b5b8a86-6c38-4e2e-9d2c-7f4b8d7a3e11"
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	swap := func(id, owner, tokenId, counterparty, want string, at time.Time) *market.Swap {
+		return &market.Swap{
+			Id:           id,
+			TokenId:      tokenId,
+			Owner:        owner,
+			Counterparty: counterparty,
+			Want:         want,
+			State:        market.SwapStateActive,
+			ExpiredAt:    at.Add(time.Hour),
+			CreatedAt:    at,
+			UpdatedAt:    at,
+		}
+	}
+
+	first := swap("3c6d3b1a-58f9-3d8e-8f3c-0c5c1d2e4f10", alice, red, bob, blue, now)
+	second := swap("7d2f0c4e-1b8a-3f6d-9e5c-4a3b2c1d0e9f", alice, red, bob, blue, now.Add(time.Minute))
+	other := swap("9a8b7c6d-5e4f-3a2b-8c1d-0e9f8a7b6c5d", alice, red, carol, blue, now)
+	err = bs.WriteSwaps([]*market.Swap{second, first, other}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		owner        string
+		tokenId      string
+		counterparty string
+		want         string
+		id           string
+	}{
+		{"earliest match", alice, red, bob, blue, first.Id},
+		{"other counterparty", alice, red, carol, blue, other.Id},
+		{"reversed", bob, blue, alice, red, ""},
+		{"wrong want", alice, red, bob, red, ""},
+		{"wrong owner", carol, red, bob, blue, ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := bs.ReadActiveSwap(tc.owner, tc.tokenId, tc.counterparty, tc.want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.id == "" && s != nil {
+				t.Fatalf("swap %s, want none", s.Id)
+			}
+			if tc.id != "" && (s == nil || s.Id != tc.id) {
+				t.Fatalf("swap %v, want %s", s, tc.id)
+			}
+		})
+	}
+
+	// bob deposits blue for red, so it matches the earliest swap of alice
+	// and both swaps are settled in the same write
+	m, err := bs.ReadActiveSwap(alice, red, bob, blue)
+	if err != nil || m == nil {
+		t.Fatalf("ReadActiveSwap %v %v", m, err)
+	}
+	s := swap("5b4a3c2d-1e0f-3a9b-8c7d-6e5f4a3b2c1d", bob, blue, alice, red, now.Add(2*time.Minute))
+	s.State, m.State = market.SwapStateSwapped, market.SwapStateSwapped
+	s.Match, m.Match = m.Id, s.Id
+	events := []*nft.Event{
+		{Id: "e1f2a3b4-c5d6-3e7f-8a9b-0c1d2e3f4a5b", Kind: nft.EventSwap, TokenId: s.TokenId, User: m.Owner},
+		{Id: "f2a3b4c5-d6e7-3f8a-9b0c-1d2e3f4a5b6c", Kind: nft.EventSwap, TokenId: m.TokenId, User: s.Owner},
+	}
+	err = bs.WriteSwaps([]*market.Swap{s, m}, events)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err = bs.ReadActiveSwap(alice, red, bob, blue)
+	if err != nil || m == nil || m.Id != second.Id {
+		t.Fatalf("ReadActiveSwap %v %v, want %s", m, err, second.Id)
+	}
+	swapped, err := bs.ReadSwap(first.Id)
+	if err != nil || swapped.State != market.SwapStateSwapped || swapped.Match != s.Id {
+		t.Fatalf("ReadSwap %v %v", swapped, err)
+	}
+	evs, err := bs.ListEvents(0, 10)
+	if err != nil || len(evs) != 2 {
+		t.Fatalf("ListEvents %d %v, want 2", len(evs), err)
+	}
+
+	expired, err := bs.ListExpiredSwaps(now.Add(time.Hour), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expired) != 1 || expired[0].Id != other.Id {
+		t.Fatalf("expired swaps %v, want %s", expired, other.Id)
+	}
+	expired, err = bs.ListExpiredSwaps(now.Add(2*time.Hour), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expired) != 2 {
+		t.Fatalf("expired swaps %d, want 2", len(expired))
+	}
+}
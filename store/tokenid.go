@@ -0,0 +1,84 @@
+package store
+
+import (
+	"log/slog"
+
+	"github.com/MixinNetwork/nfo/nft"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/gofrs/uuid"
+)
+
+const (
+	prefixMintTokenId           = "COLLECTIBLES:MINT:TOKENID:"
+	propertyMintTokenIdMigrated = "COLLECTIBLES:MINT:MIGRATION:TOKENID"
+)
+
+// ReadTokenByTokenId is the reverse of nft.BuildTokenId
+func (bs *BadgerStore) ReadTokenByTokenId(tokenId string) (*nft.Token, error) {
+	txn := bs.db.NewTransaction(false)
+	defer txn.Discard()
+
+	item, err := txn.Get([]byte(prefixMintTokenId + tokenId))
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	val, err := item.ValueCopy(nil)
+	if err != nil {
+		return nil, err
+	}
+	return bs.readMintToken(txn, val[:16], val[16:])
+}
+
+func (bs *BadgerStore) writeTokenId(txn *badger.Txn, collection, id []byte) error {
+	tokenId := nft.BuildTokenId(uuid.FromBytesOrNil(collection), id)
+	val := append(append([]byte{}, collection...), id...)
+	return txn.Set([]byte(prefixMintTokenId+tokenId), val)
+}
+
+// migrateTokenIds indexes the tokens minted before the token id index
+func (bs *BadgerStore) migrateTokenIds() error {
+	val, err := bs.ReadProperty([]byte(propertyMintTokenIdMigrated))
+	if err != nil || len(val) > 0 {
+		return err
+	}
+
+	var tokens [][]byte
+	err = bs.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = []byte(prefixMintTokenPayload)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(opts.Prefix); it.Valid(); it.Next() {
+			key := it.Item().KeyCopy(nil)
+			tokens = append(tokens, key[len(opts.Prefix):])
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	count := len(tokens)
+	for len(tokens) > 0 {
+		batch := tokens[:min(len(tokens), 1000)]
+		tokens = tokens[len(batch):]
+		err = bs.db.Update(func(txn *badger.Txn) error {
+			for _, t := range batch {
+				err := bs.writeTokenId(txn, t[:16], t[16:])
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	slog.Info("BadgerStore.migrateTokenIds", "tokens", count)
+	return bs.WriteProperty([]byte(propertyMintTokenIdMigrated), []byte{1})
+}
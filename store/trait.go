@@ -0,0 +1,131 @@
+package store
+
+import (
+	"encoding/binary"
+
+	"github.com/MixinNetwork/nfo/nft"
+	"github.com/dgraph-io/badger/v4"
+)
+
+const (
+	prefixMintTraitIndex = "COLLECTIBLES:TRAIT:INDEX:"
+	prefixMintTraitCount = "COLLECTIBLES:TRAIT:COUNT:"
+)
+
+// ListTokensByTrait returns the unburned tokens of the collection with the
+// trait, ordered by the token bytes and starting after the offset token
+func (bs *BadgerStore) ListTokensByTrait(collection []byte, key, value string, offset []byte, limit int) ([]*nft.Token, error) {
+	txn := bs.db.NewTransaction(false)
+	defer txn.Discard()
+
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	opts.Prefix = buildTraitKey(prefixMintTraitIndex, collection, key, value)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	var tokens []*nft.Token
+	for it.Seek(append(opts.Prefix, offset...)); it.Valid(); it.Next() {
+		id := it.Item().KeyCopy(nil)[len(opts.Prefix):]
+		if len(offset) > 0 && string(id) == string(offset) {
+			continue
+		}
+		t, err := bs.readMintToken(txn, collection, id)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+		if len(tokens) == limit {
+			break
+		}
+	}
+	return tokens, nil
+}
+
+// ListTraitCounts returns the number of unburned tokens of each trait in
+// the collection, ordered by the trait key and value
+func (bs *BadgerStore) ListTraitCounts(collection []byte) ([]*nft.TraitCount, error) {
+	txn := bs.db.NewTransaction(false)
+	defer txn.Discard()
+
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = append([]byte(prefixMintTraitCount), collection...)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	var counts []*nft.TraitCount
+	for it.Seek(opts.Prefix); it.Valid(); it.Next() {
+		b := it.Item().KeyCopy(nil)[len(opts.Prefix):]
+		key := string(b[1 : 1+b[0]])
+		b = b[1+b[0]:]
+		value := string(b[1 : 1+b[0]])
+		val, err := it.Item().ValueCopy(nil)
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, &nft.TraitCount{
+			Key:   key,
+			Value: value,
+			Count: int(binary.BigEndian.Uint64(val)),
+		})
+	}
+	return counts, nil
+}
+
+func (bs *BadgerStore) writeTokenTraits(txn *badger.Txn, collection, id []byte, traits []*nft.Trait) error {
+	for _, t := range traits {
+		key := buildTraitKey(prefixMintTraitIndex, collection, t.Key, t.Value)
+		err := txn.Set(append(key, id...), []byte{1})
+		if err != nil {
+			return err
+		}
+		err = bs.updateTraitCount(txn, collection, t, 1)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (bs *BadgerStore) deleteTokenTraits(txn *badger.Txn, collection, id []byte, traits []*nft.Trait) error {
+	for _, t := range traits {
+		key := buildTraitKey(prefixMintTraitIndex, collection, t.Key, t.Value)
+		err := txn.Delete(append(key, id...))
+		if err != nil {
+			return err
+		}
+		err = bs.updateTraitCount(txn, collection, t, -1)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (bs *BadgerStore) updateTraitCount(txn *badger.Txn, collection []byte, t *nft.Trait, delta int) error {
+	key := buildTraitKey(prefixMintTraitCount, collection, t.Key, t.Value)
+	var count int
+	item, err := txn.Get(key)
+	if err == nil {
+		val, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		count = int(binary.BigEndian.Uint64(val))
+	} else if err != badger.ErrKeyNotFound {
+		return err
+	}
+	count += delta
+	if count <= 0 {
+		return txn.Delete(key)
+	}
+	return txn.Set(key, binary.BigEndian.AppendUint64(nil, uint64(count)))
+}
+
+func buildTraitKey(prefix string, collection []byte, key, value string) []byte {
+	b := append([]byte(prefix), collection...)
+	b = append(b, byte(len(key)))
+	b = append(b, key...)
+	b = append(b, byte(len(value)))
+	return append(b, value...)
+}
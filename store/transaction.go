@@ -104,6 +104,10 @@ func (bs *BadgerStore) ListTransactions(state int, limit int) ([]*mtg.Transactio
 	return txs, nil
 }
 
+func (bs *BadgerStore) CountTransactions(state int) (int, error) {
+	return bs.countKeys(transactionStatePrefix(state))
+}
+
 func (bs *BadgerStore) readTransaction(txn *badger.Txn, traceId string) (*mtg.Transaction, error) {
 	key := []byte(prefixTransactionPayload + traceId)
 	item, err := txn.Get(key)
@@ -0,0 +1,165 @@
+package store
+
+import (
+	"encoding/hex"
+	"log/slog"
+
+	"github.com/MixinNetwork/nfo/nft"
+	"github.com/MixinNetwork/trusted-group/mtg"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/gofrs/uuid"
+)
+
+const (
+	prefixMintUser           = "COLLECTIBLES:MINT:USER:"
+	propertyMintUserMigrated = "COLLECTIBLES:MINT:MIGRATION:USER"
+)
+
+// ListMintsByUser lists the mints in time order after the opaque offset
+func (bs *BadgerStore) ListMintsByUser(user string, offset []byte, limit int) ([]*nft.UserMint, []byte, error) {
+	txn := bs.db.NewTransaction(false)
+	defer txn.Discard()
+
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = []byte(prefixMintUser + user)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	var mints []*nft.UserMint
+	for it.Seek(append(opts.Prefix, offset...)); it.Valid(); it.Next() {
+		key := it.Item().KeyCopy(nil)[len(opts.Prefix):]
+		if len(offset) > 0 && string(key) == string(offset) {
+			continue
+		}
+		val, err := it.Item().ValueCopy(nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		var m nft.UserMint
+		err = mtg.MsgpackUnmarshal(val, &m)
+		if err != nil {
+			return nil, nil, err
+		}
+		mints = append(mints, &m)
+		offset = key
+		if len(mints) == limit {
+			break
+		}
+	}
+	return mints, offset, nil
+}
+
+func (bs *BadgerStore) writeUserMint(txn *badger.Txn, m *nft.UserMint) error {
+	key := append([]byte(prefixMintUser+m.User), tsToBytes(m.CreatedAt)...)
+	key = append(key, m.Collection...)
+	key = append(key, m.Token...)
+	return txn.Set(key, mtg.MsgpackMarshalPanic(m))
+}
+
+// migrateUserMints indexes the mint events, then the older mint transactions
+func (bs *BadgerStore) migrateUserMints() error {
+	val, err := bs.ReadProperty([]byte(propertyMintUserMigrated))
+	if err != nil || len(val) > 0 {
+		return err
+	}
+
+	indexed := make(map[string]bool)
+	var mints []*nft.UserMint
+	err = bs.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(prefixEventPayload)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(opts.Prefix); it.Valid(); it.Next() {
+			val, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			var ev nft.Event
+			err = mtg.MsgpackUnmarshal(val, &ev)
+			if err != nil {
+				return err
+			}
+			if ev.Kind != nft.EventMint {
+				continue
+			}
+			token, err := hex.DecodeString(ev.Token)
+			if err != nil {
+				return err
+			}
+			m := &nft.UserMint{
+				User:       ev.User,
+				Collection: uuid.FromStringOrNil(ev.Collection).Bytes(),
+				Token:      token,
+				CreatedAt:  ev.CreatedAt,
+			}
+			indexed[string(m.Collection)+string(m.Token)] = true
+			mints = append(mints, m)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	err = bs.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(prefixCollectibleTransactionPayload)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(opts.Prefix); it.Valid(); it.Next() {
+			val, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			var tx mtg.CollectibleTransaction
+			err = mtg.MsgpackUnmarshal(val, &tx)
+			if err != nil {
+				return err
+			}
+			if len(tx.NFO) == 0 || len(tx.Receivers) != 1 {
+				continue
+			}
+			nm, err := mtg.DecodeNFOMemo(tx.NFO)
+			if err != nil || !nm.WillMint() {
+				continue
+			}
+			m := &nft.UserMint{
+				User:       tx.Receivers[0],
+				Collection: nm.Collection.Bytes(),
+				Token:      nm.Token,
+				CreatedAt:  tx.UpdatedAt,
+			}
+			if indexed[string(m.Collection)+string(m.Token)] {
+				continue
+			}
+			indexed[string(m.Collection)+string(m.Token)] = true
+			mints = append(mints, m)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	count := len(mints)
+	for len(mints) > 0 {
+		batch := mints[:min(len(mints), 1000)]
+		mints = mints[len(batch):]
+		err = bs.db.Update(func(txn *badger.Txn) error {
+			for _, m := range batch {
+				err := bs.writeUserMint(txn, m)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	slog.Info("BadgerStore.migrateUserMints", "mints", count)
+	return bs.WriteProperty([]byte(propertyMintUserMigrated), []byte{1})
+}
@@ -0,0 +1,116 @@
+package store
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/MixinNetwork/nfo/webhook"
+	"github.com/MixinNetwork/trusted-group/mtg"
+	"github.com/dgraph-io/badger/v4"
+)
+
+const (
+	prefixWebhookPayload  = "WEBHOOK:PAYLOAD:"
+	prefixWebhookQueue    = "WEBHOOK:QUEUE:"
+	propertyWebhookCursor = "WEBHOOK:CURSOR"
+)
+
+func (bs *BadgerStore) ReadWebhookCursor() (uint64, error) {
+	val, err := bs.ReadProperty([]byte(propertyWebhookCursor))
+	if err != nil || len(val) == 0 {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(val), nil
+}
+
+func (bs *BadgerStore) WriteWebhookDeliveries(deliveries []*webhook.Delivery, cursor uint64) error {
+	return bs.db.Update(func(txn *badger.Txn) error {
+		for _, d := range deliveries {
+			err := bs.writeWebhookDelivery(txn, d)
+			if err != nil {
+				return err
+			}
+		}
+		val := binary.BigEndian.AppendUint64(nil, cursor)
+		return txn.Set([]byte(propertyWebhookCursor), val)
+	})
+}
+
+func (bs *BadgerStore) ListWebhookDeliveries(until time.Time, limit int) ([]*webhook.Delivery, error) {
+	txn := bs.db.NewTransaction(false)
+	defer txn.Discard()
+
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	opts.Prefix = []byte(prefixWebhookQueue)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	var deliveries []*webhook.Delivery
+	for it.Seek(opts.Prefix); it.Valid(); it.Next() {
+		key := it.Item().Key()
+		ts := binary.BigEndian.Uint64(key[len(opts.Prefix) : len(opts.Prefix)+8])
+		if int64(ts) > until.UnixNano() {
+			break
+		}
+		id := string(key[len(opts.Prefix)+8:])
+		d, err := bs.readWebhookDelivery(txn, id)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+		if len(deliveries) == limit {
+			break
+		}
+	}
+	return deliveries, nil
+}
+
+func (bs *BadgerStore) UpdateWebhookDelivery(old, d *webhook.Delivery) error {
+	return bs.db.Update(func(txn *badger.Txn) error {
+		err := txn.Delete(buildWebhookQueueKey(old))
+		if err != nil {
+			return err
+		}
+		return bs.writeWebhookDelivery(txn, d)
+	})
+}
+
+func (bs *BadgerStore) DeleteWebhookDelivery(d *webhook.Delivery) error {
+	return bs.db.Update(func(txn *badger.Txn) error {
+		err := txn.Delete(buildWebhookQueueKey(d))
+		if err != nil {
+			return err
+		}
+		return txn.Delete([]byte(prefixWebhookPayload + d.Id))
+	})
+}
+
+func (bs *BadgerStore) writeWebhookDelivery(txn *badger.Txn, d *webhook.Delivery) error {
+	key := []byte(prefixWebhookPayload + d.Id)
+	err := txn.Set(key, mtg.MsgpackMarshalPanic(d))
+	if err != nil {
+		return err
+	}
+	return txn.Set(buildWebhookQueueKey(d), []byte{1})
+}
+
+func (bs *BadgerStore) readWebhookDelivery(txn *badger.Txn, id string) (*webhook.Delivery, error) {
+	key := []byte(prefixWebhookPayload + id)
+	item, err := txn.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	val, err := item.ValueCopy(nil)
+	if err != nil {
+		return nil, err
+	}
+	var d webhook.Delivery
+	err = mtg.MsgpackUnmarshal(val, &d)
+	return &d, err
+}
+
+func buildWebhookQueueKey(d *webhook.Delivery) []byte {
+	key := append([]byte(prefixWebhookQueue), tsToBytes(d.NextAt)...)
+	return append(key, d.Id...)
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/MixinNetwork/nfo/nft"
+	"github.com/gofrs/uuid"
+)
+
+const (
+	tokensLimitDefault = 100
+	tokensLimitMax     = 500
+)
+
+type TokenView struct {
+	Collection string            `json:"collection"`
+	Token      string            `json:"token"`
+	TokenId    string            `json:"token_id"`
+	Burned     bool              `json:"burned"`
+	Traits     map[string]string `json:"traits,omitempty"`
+}
+
+type ProvenanceView struct {
+	Kind      string    `json:"kind"`
+	Hash      string    `json:"hash"`
+	Users     []string  `json:"users"`
+	Threshold int       `json:"threshold"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TraitView struct {
+	Key    string  `json:"key"`
+	Value  string  `json:"value"`
+	Count  int     `json:"count"`
+	Rarity float64 `json:"rarity"`
+}
+
+// handleToken reads the minted token by the collection and the token in hex
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	collection, err := uuid.FromString(query.Get("collection"))
+	if err != nil {
+		renderError(w, http.StatusBadRequest, fmt.Errorf("invalid collection %s", query.Get("collection")))
+		return
+	}
+	token, err := hex.DecodeString(query.Get("token"))
+	if err != nil || len(token) == 0 {
+		renderError(w, http.StatusBadRequest, fmt.Errorf("invalid token %s", query.Get("token")))
+		return
+	}
+
+	t, err := s.store.ReadMintToken(collection.Bytes(), token)
+	if err != nil {
+		renderError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if t == nil {
+		renderError(w, http.StatusNotFound, fmt.Errorf("token not found"))
+		return
+	}
+	renderJSON(w, http.StatusOK, buildTokenView(collection, t))
+}
+
+// handleProvenance lists the chain of custody of the token by the Mixin
+// token id, or by the collection and the token in hex
+func (s *Server) handleProvenance(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	tokenId := query.Get("token_id")
+	if tokenId == "" {
+		collection, err := uuid.FromString(query.Get("collection"))
+		if err != nil {
+			renderError(w, http.StatusBadRequest, fmt.Errorf("invalid collection %s", query.Get("collection")))
+			return
+		}
+		token, err := hex.DecodeString(query.Get("token"))
+		if err != nil || len(token) == 0 {
+			renderError(w, http.StatusBadRequest, fmt.Errorf("invalid token %s", query.Get("token")))
+			return
+		}
+		tokenId = nft.BuildTokenId(collection, token)
+	} else if uuid.FromStringOrNil(tokenId).String() != tokenId {
+		renderError(w, http.StatusBadRequest, fmt.Errorf("invalid token id %s", tokenId))
+		return
+	}
+
+	history, err := s.store.ListTokenProvenance(tokenId)
+	if err != nil {
+		renderError(w, http.StatusInternalServerError, err)
+		return
+	}
+	views := make([]*ProvenanceView, len(history))
+	for i, p := range history {
+		views[i] = &ProvenanceView{
+			Kind:      p.Kind,
+			Hash:      p.Hash,
+			Users:     p.Users,
+			Threshold: p.Threshold,
+			CreatedAt: p.CreatedAt,
+		}
+	}
+	renderJSON(w, http.StatusOK, map[string]any{"token_id": tokenId, "provenance": views})
+}
+
+// handleTokensByTrait lists the tokens of the collection with the trait,
+// and the offset is the last token in hex of the previous page
+func (s *Server) handleTokensByTrait(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	collection, err := uuid.FromString(query.Get("collection"))
+	if err != nil {
+		renderError(w, http.StatusBadRequest, fmt.Errorf("invalid collection %s", query.Get("collection")))
+		return
+	}
+	key, value := query.Get("key"), query.Get("value")
+	if key == "" || value == "" {
+		renderError(w, http.StatusBadRequest, fmt.Errorf("invalid trait %s %s", key, value))
+		return
+	}
+	offset, err := hex.DecodeString(query.Get("offset"))
+	if err != nil {
+		renderError(w, http.StatusBadRequest, fmt.Errorf("invalid offset %s", query.Get("offset")))
+		return
+	}
+	limit, err := parseLimit(query.Get("limit"), tokensLimitDefault, tokensLimitMax)
+	if err != nil {
+		renderError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tokens, err := s.store.ListTokensByTrait(collection.Bytes(), key, value, offset, limit)
+	if err != nil {
+		renderError(w, http.StatusInternalServerError, err)
+		return
+	}
+	views := make([]*TokenView, len(tokens))
+	for i, t := range tokens {
+		views[i] = buildTokenView(collection, t)
+	}
+	renderJSON(w, http.StatusOK, map[string]any{"tokens": views})
+}
+
+// handleTraits counts the tokens of each trait in the collection, and the
+// rarity is the count over the unburned tokens of the collection
+func (s *Server) handleTraits(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	collection, err := uuid.FromString(query.Get("collection"))
+	if err != nil {
+		renderError(w, http.StatusBadRequest, fmt.Errorf("invalid collection %s", query.Get("collection")))
+		return
+	}
+	og, err := s.store.ReadMintCollection(collection.Bytes())
+	if err != nil {
+		renderError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if og == nil {
+		renderError(w, http.StatusNotFound, fmt.Errorf("collection not found"))
+		return
+	}
+	counts, err := s.store.ListTraitCounts(og.Key)
+	if err != nil {
+		renderError(w, http.StatusInternalServerError, err)
+		return
+	}
+	unburned := og.Circulation - og.Burned
+	views := make([]*TraitView, len(counts))
+	for i, c := range counts {
+		views[i] = &TraitView{
+			Key:   c.Key,
+			Value: c.Value,
+			Count: c.Count,
+		}
+		if unburned > 0 {
+			views[i].Rarity = float64(c.Count) / float64(unburned)
+		}
+	}
+	renderJSON(w, http.StatusOK, map[string]any{"circulation": og.Circulation, "unburned": unburned, "traits": views})
+}
+
+func buildTokenView(collection uuid.UUID, t *nft.Token) *TokenView {
+	view := &TokenView{
+		Collection: collection.String(),
+		Token:      hex.EncodeToString(t.Key),
+		TokenId:    nft.BuildTokenId(collection, t.Key),
+		Burned:     t.Burned,
+	}
+	if len(t.Traits) > 0 {
+		view.Traits = make(map[string]string)
+		for _, tt := range t.Traits {
+			view.Traits[tt.Key] = tt.Value
+		}
+	}
+	return view
+}
+
+func parseLimit(s string, def, max int) (int, error) {
+	if s == "" {
+		return def, nil
+	}
+	limit, err := strconv.Atoi(s)
+	if err != nil || limit <= 0 || limit > max {
+		return 0, fmt.Errorf("invalid limit %s", s)
+	}
+	return limit, nil
+}
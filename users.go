@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/MixinNetwork/nfo/nft"
+	"github.com/gofrs/uuid"
+)
+
+type UserMintView struct {
+	Collection string    `json:"collection"`
+	Token      string    `json:"token"`
+	TokenId    string    `json:"token_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// handleUserMints lists the tokens minted by the user, and the offset in
+// hex is returned by the previous page
+func (s *Server) handleUserMints(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	user, err := uuid.FromString(query.Get("user"))
+	if err != nil {
+		renderError(w, http.StatusBadRequest, fmt.Errorf("invalid user %s", query.Get("user")))
+		return
+	}
+	offset, err := hex.DecodeString(query.Get("offset"))
+	if err != nil {
+		renderError(w, http.StatusBadRequest, fmt.Errorf("invalid offset %s", query.Get("offset")))
+		return
+	}
+	limit, err := parseLimit(query.Get("limit"), tokensLimitDefault, tokensLimitMax)
+	if err != nil {
+		renderError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	mints, next, err := s.store.ListMintsByUser(user.String(), offset, limit)
+	if err != nil {
+		renderError(w, http.StatusInternalServerError, err)
+		return
+	}
+	views := make([]*UserMintView, len(mints))
+	for i, m := range mints {
+		views[i] = buildUserMintView(m)
+	}
+	renderJSON(w, http.StatusOK, map[string]any{"mints": views, "offset": hex.EncodeToString(next)})
+}
+
+func buildUserMintView(m *nft.UserMint) *UserMintView {
+	collection := uuid.FromBytesOrNil(m.Collection)
+	return &UserMintView{
+		Collection: collection.String(),
+		Token:      hex.EncodeToString(m.Token),
+		TokenId:    nft.BuildTokenId(collection, m.Token),
+		CreatedAt:  m.CreatedAt,
+	}
+}
+
+// runUser prints the tokens minted by the user with `user mints <uuid>`,
+// by paging through the node HTTP API
+func runUser(ctx context.Context, conf *Configuration, args []string) error {
+	if len(args) == 0 || args[0] != "mints" {
+		return fmt.Errorf("usage: user mints [-node url] <uuid>")
+	}
+	fs := flag.NewFlagSet("user mints", flag.ExitOnError)
+	node := fs.String("node", "", "node HTTP API, defaults to the [http] listen")
+	fs.Parse(args[1:])
+
+	user, err := uuid.FromString(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("invalid user %s", fs.Arg(0))
+	}
+	if *node == "" {
+		*node = "http://" + conf.HTTP.Listen
+	}
+
+	var offset string
+	for {
+		mints, next, err := readUserMints(ctx, *node, user, offset)
+		if err != nil {
+			return err
+		}
+		for _, m := range mints {
+			fmt.Printf("%s\t%s\t%s\t%s\n", m.CreatedAt.Format(time.RFC3339), m.Collection, m.Token, m.TokenId)
+		}
+		if len(mints) < tokensLimitMax {
+			return nil
+		}
+		offset = next
+	}
+}
+
+func readUserMints(ctx context.Context, node string, user uuid.UUID, offset string) ([]*UserMintView, string, error) {
+	query := url.Values{}
+	query.Set("user", user.String())
+	query.Set("limit", fmt.Sprint(tokensLimitMax))
+	query.Set("offset", offset)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, node+"/users/mints?"+query.Encode(), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("node %s status %d", node, resp.StatusCode)
+	}
+
+	var body struct {
+		Mints  []*UserMintView `json:"mints"`
+		Offset string          `json:"offset"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	return body.Mints, body.Offset, err
+}
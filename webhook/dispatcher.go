@@ -0,0 +1,181 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/MixinNetwork/nfo/nft"
+	"github.com/fox-one/mixin-sdk-go"
+)
+
+const (
+	HeaderSignature = "X-NFO-Signature"
+	HeaderTimestamp = "X-NFO-Timestamp"
+	HeaderDelivery  = "X-NFO-Delivery"
+
+	eventsBatchSize = 100
+	backoffBase     = 5 * time.Second
+	backoffMax      = time.Hour
+	pollInterval    = time.Second
+)
+
+// Dispatcher tails the event log and POSTs the mint outcomes to the
+// configured URLs, the deliveries are queued in the store so that they
+// survive restarts and are retried with exponential backoff
+type Dispatcher struct {
+	store       Store
+	urls        []string
+	secret      []byte
+	maxAttempts int
+	client      *http.Client
+}
+
+func NewDispatcher(store Store, urls []string, secret string, maxAttempts int) *Dispatcher {
+	if maxAttempts <= 0 {
+		maxAttempts = 10
+	}
+	return &Dispatcher{
+		store:       store,
+		urls:        urls,
+		secret:      []byte(secret),
+		maxAttempts: maxAttempts,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (d *Dispatcher) Run(ctx context.Context) {
+	for ctx.Err() == nil {
+		err := d.enqueueEvents()
+		if err != nil {
+			slog.Error("Dispatcher.enqueueEvents", "error", err)
+		}
+		err = d.deliverQueue(ctx)
+		if err != nil {
+			slog.Error("Dispatcher.deliverQueue", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// Sign returns the hex HMAC-SHA256 of timestamp || "." || body
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (d *Dispatcher) enqueueEvents() error {
+	for {
+		cursor, err := d.store.ReadWebhookCursor()
+		if err != nil {
+			return err
+		}
+		events, err := d.store.ListEvents(cursor, eventsBatchSize)
+		if err != nil || len(events) == 0 {
+			return err
+		}
+
+		var deliveries []*Delivery
+		for _, ev := range events {
+			cursor = ev.Sequence
+			if ev.Kind != nft.EventMint && ev.Kind != nft.EventReject {
+				continue
+			}
+			payload, err := json.Marshal(ev)
+			if err != nil {
+				panic(err)
+			}
+			for _, u := range d.urls {
+				deliveries = append(deliveries, &Delivery{
+					Id:        mixin.UniqueConversationID(ev.Id, u),
+					URL:       u,
+					Sequence:  ev.Sequence,
+					Payload:   payload,
+					NextAt:    time.Now(),
+					CreatedAt: time.Now(),
+				})
+			}
+		}
+		err = d.store.WriteWebhookDeliveries(deliveries, cursor)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (d *Dispatcher) deliverQueue(ctx context.Context) error {
+	deliveries, err := d.store.ListWebhookDeliveries(time.Now(), eventsBatchSize)
+	if err != nil {
+		return err
+	}
+	for _, dl := range deliveries {
+		err := d.post(ctx, dl)
+		if err == nil {
+			err = d.store.DeleteWebhookDelivery(dl)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		slog.Warn("Dispatcher.post", "delivery", dl.Id, "url", dl.URL, "sequence", dl.Sequence, "attempts", dl.Attempts+1, "error", err)
+		if dl.Attempts+1 >= d.maxAttempts {
+			slog.Error("Dispatcher.drop", "delivery", dl.Id, "url", dl.URL, "sequence", dl.Sequence)
+			err = d.store.DeleteWebhookDelivery(dl)
+		} else {
+			next := *dl
+			next.Attempts += 1
+			next.NextAt = time.Now().Add(backoff(next.Attempts))
+			err = d.store.UpdateWebhookDelivery(dl, &next)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Dispatcher) post(ctx context.Context, dl *Delivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, dl.URL, bytes.NewReader(dl.Payload))
+	if err != nil {
+		return err
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderDelivery, dl.Id)
+	req.Header.Set(HeaderTimestamp, ts)
+	req.Header.Set(HeaderSignature, "sha256="+Sign(d.secret, ts, dl.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func backoff(attempts int) time.Duration {
+	d := backoffBase << (attempts - 1)
+	if d <= 0 || d > backoffMax {
+		return backoffMax
+	}
+	return d
+}
@@ -0,0 +1,27 @@
+package webhook
+
+import (
+	"time"
+
+	"github.com/MixinNetwork/nfo/nft"
+)
+
+type Store interface {
+	ListEvents(offset uint64, limit int) ([]*nft.Event, error)
+
+	ReadWebhookCursor() (uint64, error)
+	WriteWebhookDeliveries(deliveries []*Delivery, cursor uint64) error
+	ListWebhookDeliveries(until time.Time, limit int) ([]*Delivery, error)
+	UpdateWebhookDelivery(old, d *Delivery) error
+	DeleteWebhookDelivery(d *Delivery) error
+}
+
+type Delivery struct {
+	Id        string
+	URL       string
+	Sequence  uint64
+	Payload   []byte
+	Attempts  int
+	NextAt    time.Time
+	CreatedAt time.Time
+}